@@ -0,0 +1,187 @@
+package iprange
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"sync"
+)
+
+// Allocator allocates IP addresses from a pool defined by IPRanges. Both
+// the free and the used IP addresses of the pool are kept as merged IP
+// ranges rather than a bitmap, so the memory it takes depends on how
+// fragmented the pool is, not on its size. An IPv6 /64 pool is as cheap as
+// an IPv4 /24 one.
+//
+// An Allocator is safe for concurrent use by multiple goroutines.
+type Allocator struct {
+	mu      sync.Mutex
+	version family
+	// pool is never modified, while free and used are kept merged as IP
+	// addresses are allocated and released.
+	pool []ipRange
+	free []ipRange
+	used []ipRange
+
+	strategy Strategy
+}
+
+// NewAllocator returns an Allocator whose pool is IPRanges pool, with all
+// of its IP addresses free.
+func NewAllocator(pool *IPRanges) *Allocator {
+	rs := pool.Merge()
+
+	return &Allocator{
+		version: rs.version,
+		pool:    rs.ranges,
+		free:    append([]ipRange(nil), rs.ranges...),
+	}
+}
+
+// Pool returns the pool of Allocator a.
+func (a *Allocator) Pool() *IPRanges {
+	return &IPRanges{
+		version: a.version,
+		ranges:  a.pool,
+		merged:  true,
+	}
+}
+
+// Allocate allocates the lowest free IP address of the pool, or the one
+// picked by the Strategy set by SetStrategy. The error errPoolExhausted
+// will be returned when there is no free IP address.
+func (a *Allocator) Allocate() (net.IP, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.free) == 0 {
+		return nil, errPoolExhausted
+	}
+
+	if a.strategy != nil {
+		return a.allocateWith(a.strategy)
+	}
+
+	w := a.free[0].start
+	a.allocate(w)
+
+	return w.toIP(), nil
+}
+
+// AllocateSpecific allocates net.IP ip. The error errNotInPool will be
+// returned when ip does not pertain to the pool, and errAllocated when ip
+// has been allocated.
+func (a *Allocator) AllocateSpecific(ip net.IP) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	w := ipToXIP(ip)
+	if !a.inPool(w) {
+		return fmt.Errorf("%w: %s", errNotInPool, ip)
+	}
+	if !rangesContain(a.free, w) {
+		return fmt.Errorf("%w: %s", errAllocated, ip)
+	}
+	a.allocate(w)
+
+	return nil
+}
+
+// Release releases net.IP ip back to the pool. The error errNotInPool will
+// be returned when ip does not pertain to the pool, and errNotAllocated
+// when ip has not been allocated.
+func (a *Allocator) Release(ip net.IP) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	w := ipToXIP(ip)
+	if !a.inPool(w) {
+		return fmt.Errorf("%w: %s", errNotInPool, ip)
+	}
+	if !rangesContain(a.used, w) {
+		return fmt.Errorf("%w: %s", errNotAllocated, ip)
+	}
+	a.release(w)
+
+	return nil
+}
+
+// Has reports whether net.IP ip has been allocated.
+func (a *Allocator) Has(ip net.IP) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return rangesContain(a.used, ipToXIP(ip))
+}
+
+// Free returns the number of free IP addresses.
+func (a *Allocator) Free() *big.Int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return rangesSize(a.free)
+}
+
+// Used returns the number of allocated IP addresses.
+func (a *Allocator) Used() *big.Int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return rangesSize(a.used)
+}
+
+// Allocated returns the allocated IP addresses as IPRanges, which is
+// merged.
+func (a *Allocator) Allocated() *IPRanges {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.usedRanges()
+}
+
+// usedRanges returns a copy of the allocated IP addresses as IPRanges.
+func (a *Allocator) usedRanges() *IPRanges {
+	return &IPRanges{
+		version: a.version,
+		ranges:  append([]ipRange(nil), a.used...),
+		merged:  true,
+	}
+}
+
+// inPool reports whether xIP w pertains to the pool.
+func (a *Allocator) inPool(w xIP) bool {
+	return w.version() == a.version && rangesContain(a.pool, w)
+}
+
+// allocate moves free xIP w to the used IP addresses.
+func (a *Allocator) allocate(w xIP) {
+	r := ipRange{start: w, end: w}
+	a.free = removeRange(a.free, r)
+	a.used = addRange(a.used, r)
+}
+
+// release moves used xIP w to the free IP addresses.
+func (a *Allocator) release(w xIP) {
+	r := ipRange{start: w, end: w}
+	a.used = removeRange(a.used, r)
+	a.free = addRange(a.free, r)
+}
+
+// rangesContain reports whether merged ranges contain xIP w.
+func rangesContain(ranges []ipRange, w xIP) bool {
+	i := searchRanges(ranges, func(r ipRange) bool {
+		return r.end.cmp(w) >= 0
+	})
+
+	return i < len(ranges) && ranges[i].start.cmp(w) <= 0 && ranges[i].start.version() == w.version()
+}
+
+// rangesSize calculates the total number of IP addresses in ranges.
+func rangesSize(ranges []ipRange) *big.Int {
+	n := big.NewInt(0)
+	for _, r := range ranges {
+		n.Add(n, r.size())
+	}
+
+	return n
+}
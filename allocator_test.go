@@ -0,0 +1,180 @@
+package iprange
+
+import (
+	"errors"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+)
+
+func TestAllocatorAllocate(t *testing.T) {
+	t.Parallel()
+	pool, err := Parse("172.18.0.1-2", "172.18.0.10")
+	if err != nil {
+		t.Fatalf("Parse() err %q", err)
+	}
+
+	a := NewAllocator(pool)
+	want := []string{"172.18.0.1", "172.18.0.2", "172.18.0.10"}
+	for _, w := range want {
+		ip, err := a.Allocate()
+		if err != nil {
+			t.Fatalf("Allocator(%v).Allocate() err %q", pool, err)
+		}
+		if ip.String() != w {
+			t.Fatalf("Allocator(%v).Allocate() = %v, want %v", pool, ip, w)
+		}
+	}
+	if _, err := a.Allocate(); !errors.Is(err, errPoolExhausted) {
+		t.Fatalf("Allocator(%v).Allocate() err %v, want %v", pool, err, errPoolExhausted)
+	}
+
+	if err := a.Release(net.ParseIP("172.18.0.2")); err != nil {
+		t.Fatalf("Allocator(%v).Release(172.18.0.2) err %q", pool, err)
+	}
+	if ip, _ := a.Allocate(); ip.String() != "172.18.0.2" {
+		t.Fatalf("Allocator(%v).Allocate() = %v after Release, want 172.18.0.2", pool, ip)
+	}
+}
+
+var allocatorTests = []struct {
+	name     string
+	pool     []string
+	allocate []string
+	release  []string
+	used     string
+	free     *big.Int
+	err      error
+}{
+	{
+		name:     "specific",
+		pool:     []string{"172.18.0.0/24"},
+		allocate: []string{"172.18.0.1", "172.18.0.3", "172.18.0.2"},
+		release:  []string{"172.18.0.3"},
+		used:     "172.18.0.1-172.18.0.2",
+		free:     big.NewInt(254),
+	},
+	{
+		name:     "IPv6 /64",
+		pool:     []string{"fd00::/64"},
+		allocate: []string{"fd00::1", "fd00::ffff:ffff:ffff:ffff"},
+		used:     "[fd00::1 fd00::ffff:ffff:ffff:ffff]",
+		free:     new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 64), big.NewInt(2)),
+	},
+	{
+		name:     "allocated",
+		pool:     []string{"172.18.0.0/24"},
+		allocate: []string{"172.18.0.1", "172.18.0.1"},
+		err:      errAllocated,
+	},
+	{
+		name:     "not in pool",
+		pool:     []string{"172.18.0.0/24"},
+		allocate: []string{"172.18.1.1"},
+		err:      errNotInPool,
+	},
+	{
+		name:     "diff version",
+		pool:     []string{"172.18.0.0/24"},
+		allocate: []string{"fd00::1"},
+		err:      errNotInPool,
+	},
+	{
+		name:    "not allocated",
+		pool:    []string{"172.18.0.0/24"},
+		release: []string{"172.18.0.1"},
+		err:     errNotAllocated,
+	},
+	{
+		name:    "release not in pool",
+		pool:    []string{"172.18.0.0/24"},
+		release: []string{"172.18.1.1"},
+		err:     errNotInPool,
+	},
+}
+
+func TestAllocator(t *testing.T) {
+	t.Parallel()
+	for _, test := range allocatorTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			pool, err := Parse(test.pool...)
+			if err != nil {
+				t.Fatalf("Parse(%q) err %q", test.pool, err)
+			}
+
+			a := NewAllocator(pool)
+			for _, ip := range test.allocate {
+				if err = a.AllocateSpecific(net.ParseIP(ip)); err != nil {
+					break
+				}
+			}
+			if err == nil {
+				for _, ip := range test.release {
+					if err = a.Release(net.ParseIP(ip)); err != nil {
+						break
+					}
+				}
+			}
+			if err != nil || test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("Allocator(%v) err %v, want %v", pool, err, test.err)
+				}
+				return
+			}
+
+			if used := a.Allocated().String(); used != test.used {
+				t.Fatalf("Allocator(%v).Allocated() = %v, want %v", pool, used, test.used)
+			}
+			if free := a.Free(); free.Cmp(test.free) != 0 {
+				t.Fatalf("Allocator(%v).Free() = %v, want %v", pool, free, test.free)
+			}
+			used := new(big.Int).Sub(pool.Size(), test.free)
+			if n := a.Used(); n.Cmp(used) != 0 {
+				t.Fatalf("Allocator(%v).Used() = %v, want %v", pool, n, used)
+			}
+			for _, ip := range test.allocate {
+				if has := a.Has(net.ParseIP(ip)); has != a.Allocated().Contains(net.ParseIP(ip)) {
+					t.Fatalf("Allocator(%v).Has(%v) = %v", pool, ip, has)
+				}
+			}
+		})
+	}
+}
+
+func TestAllocatorConcurrent(t *testing.T) {
+	t.Parallel()
+	pool, err := Parse("172.18.0.0/24")
+	if err != nil {
+		t.Fatalf("Parse() err %q", err)
+	}
+
+	a := NewAllocator(pool)
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		ips = make(map[string]bool)
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				ip, err := a.Allocate()
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				ips[ip.String()] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(ips) != 256 {
+		t.Fatalf("Allocator(%v) allocated %d distinct IP addresses, want 256", pool, len(ips))
+	}
+}
@@ -0,0 +1,258 @@
+package iprange
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+// IPRangesBuilder builds an IPRanges incrementally, without parsing any IP
+// range string. The IP ranges it holds are always kept merged (ordered and
+// deduplicated) as they are added or removed, and Build produces an
+// IPRanges from them. The zero value of IPRangesBuilder is ready to use.
+//
+// The IP version of an IPRangesBuilder is decided by the first IP address
+// added to it. Adding or removing IP addresses of the other IP version
+// records the error errDualStackIPRanges, and adding or removing invalid
+// IP addresses records the error errInvalidIPRangeFormat. Only the first
+// error is kept, which is returned by Build.
+type IPRangesBuilder struct {
+	version family
+	ranges  []ipRange
+	err     error
+}
+
+// AddIP adds net.IP ip to IPRangesBuilder b.
+func (b *IPRangesBuilder) AddIP(ip net.IP) {
+	w := ipToXIP(ip)
+	if !b.check(w, w, ip) {
+		return
+	}
+
+	b.add(ipRange{start: w, end: w})
+}
+
+// AddRange adds the IP range from net.IP start to end to IPRangesBuilder b.
+func (b *IPRangesBuilder) AddRange(start, end net.IP) {
+	s, e := ipToXIP(start), ipToXIP(end)
+	if !b.check(s, e, fmt.Sprintf("%s-%s", start, end)) {
+		return
+	}
+
+	b.add(ipRange{start: s, end: e})
+}
+
+// AddPrefix adds netip.Prefix p to IPRangesBuilder b.
+func (b *IPRangesBuilder) AddPrefix(p netip.Prefix) {
+	r, err := prefixToRange(p)
+	if err != nil {
+		b.setErr(err)
+		return
+	}
+	if !b.check(r.start, r.end, p) {
+		return
+	}
+
+	b.add(r)
+}
+
+// AddSet adds all the IP addresses of IPRanges rs to IPRangesBuilder b.
+func (b *IPRangesBuilder) AddSet(rs *IPRanges) {
+	if len(rs.ranges) == 0 {
+		return
+	}
+	if !b.checkVersion(rs.version) {
+		return
+	}
+
+	b.ranges = mergeRanges(append(b.ranges, rs.ranges...))
+}
+
+// RemoveIP removes net.IP ip from IPRangesBuilder b.
+func (b *IPRangesBuilder) RemoveIP(ip net.IP) {
+	w := ipToXIP(ip)
+	if !b.check(w, w, ip) {
+		return
+	}
+
+	b.remove(ipRange{start: w, end: w})
+}
+
+// RemoveRange removes the IP range from net.IP start to end from
+// IPRangesBuilder b.
+func (b *IPRangesBuilder) RemoveRange(start, end net.IP) {
+	s, e := ipToXIP(start), ipToXIP(end)
+	if !b.check(s, e, fmt.Sprintf("%s-%s", start, end)) {
+		return
+	}
+
+	b.remove(ipRange{start: s, end: e})
+}
+
+// RemovePrefix removes netip.Prefix p from IPRangesBuilder b.
+func (b *IPRangesBuilder) RemovePrefix(p netip.Prefix) {
+	r, err := prefixToRange(p)
+	if err != nil {
+		b.setErr(err)
+		return
+	}
+	if !b.check(r.start, r.end, p) {
+		return
+	}
+
+	b.remove(r)
+}
+
+// RemoveSet removes all the IP addresses of IPRanges rs from
+// IPRangesBuilder b.
+func (b *IPRangesBuilder) RemoveSet(rs *IPRanges) {
+	if len(rs.ranges) == 0 {
+		return
+	}
+	if !b.checkVersion(rs.version) {
+		return
+	}
+
+	b.ranges = diffRanges(b.ranges, rs.Merge().ranges)
+}
+
+// Build returns the IPRanges that IPRangesBuilder b holds, which is always
+// merged. IPRangesBuilder b can still be used after Build, which does not
+// affect the IPRanges returned.
+func (b *IPRangesBuilder) Build() (*IPRanges, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	return &IPRanges{
+		version: b.version,
+		ranges:  append([]ipRange(nil), b.ranges...),
+		merged:  true,
+	}, nil
+}
+
+// check reports whether the IP range from xIP start to end is valid and
+// has the same IP version as IPRangesBuilder b. Otherwise, the error is
+// recorded, where v describes the IP range.
+func (b *IPRangesBuilder) check(start, end xIP, v any) bool {
+	if start.version() == Unknown || start.version() != end.version() || end.cmp(start) < 0 {
+		b.setErr(fmt.Errorf("%w: %v", errInvalidIPRangeFormat, v))
+		return false
+	}
+
+	return b.checkVersion(start.version())
+}
+
+// checkVersion reports whether IP version v matches IPRangesBuilder b,
+// which adopts v if it has no IP version yet.
+func (b *IPRangesBuilder) checkVersion(v family) bool {
+	if b.version == Unknown {
+		b.version = v
+	}
+
+	if b.version != v {
+		b.setErr(errDualStackIPRanges)
+		return false
+	}
+
+	return true
+}
+
+// setErr records err unless there is already an error.
+func (b *IPRangesBuilder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// add adds ipRange r to IPRangesBuilder b.
+func (b *IPRangesBuilder) add(r ipRange) {
+	b.ranges = addRange(b.ranges, r)
+}
+
+// remove removes ipRange r from IPRangesBuilder b.
+func (b *IPRangesBuilder) remove(r ipRange) {
+	b.ranges = removeRange(b.ranges, r)
+}
+
+// addRange adds ipRange r to merged ranges in place, and returns the
+// merged result.
+func addRange(ranges []ipRange, r ipRange) []ipRange {
+	// ranges[lo:hi] are the ones overlapping or adjacent to r.
+	lo := searchRanges(ranges, func(a ipRange) bool {
+		return a.end.cmp(r.start) >= 0 || a.end.next() == r.start
+	})
+	hi := lo + searchRanges(ranges[lo:], func(a ipRange) bool {
+		return a.start.cmp(r.end) > 0 && a.start != r.end.next()
+	})
+
+	if lo == hi {
+		ranges = append(ranges, ipRange{})
+		copy(ranges[lo+1:], ranges[lo:])
+		ranges[lo] = r
+		return ranges
+	}
+
+	ranges[lo] = ipRange{
+		start: minXIP(ranges[lo].start, r.start),
+		end:   maxXIP(ranges[hi-1].end, r.end),
+	}
+
+	return append(ranges[:lo+1], ranges[hi:]...)
+}
+
+// removeRange removes ipRange r from merged ranges in place, and returns
+// the merged result.
+func removeRange(ranges []ipRange, r ipRange) []ipRange {
+	// ranges[lo:hi] are the ones overlapping r.
+	lo := searchRanges(ranges, func(a ipRange) bool {
+		return a.end.cmp(r.start) >= 0
+	})
+	hi := lo + searchRanges(ranges[lo:], func(a ipRange) bool {
+		return a.start.cmp(r.end) > 0
+	})
+
+	if lo == hi {
+		return ranges
+	}
+
+	// What is left of ranges[lo:hi] after removing r.
+	rest := make([]ipRange, 0, 2)
+	if ranges[lo].start.cmp(r.start) < 0 {
+		rest = append(rest, ipRange{
+			start: ranges[lo].start,
+			end:   r.start.prev(),
+		})
+	}
+	if ranges[hi-1].end.cmp(r.end) > 0 {
+		rest = append(rest, ipRange{
+			start: r.end.next(),
+			end:   ranges[hi-1].end,
+		})
+	}
+
+	if n := len(rest) - (hi - lo); n > 0 {
+		ranges = append(ranges, make([]ipRange, n)...)
+	}
+	tail := copy(ranges[lo+len(rest):], ranges[hi:])
+	copy(ranges[lo:], rest)
+
+	return ranges[:lo+len(rest)+tail]
+}
+
+// searchRanges returns the smallest index i in [0, len(ranges)) at which
+// f(ranges[i]) is true, assuming that f(ranges[i]) == true implies
+// f(ranges[i+1]) == true. It returns len(ranges) if there is no such index.
+func searchRanges(ranges []ipRange, f func(ipRange) bool) int {
+	i, j := 0, len(ranges)
+	for i < j {
+		h := int(uint(i+j) >> 1)
+		if !f(ranges[h]) {
+			i = h + 1
+		} else {
+			j = h
+		}
+	}
+
+	return i
+}
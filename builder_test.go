@@ -0,0 +1,133 @@
+package iprange
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var ipRangesBuilderTests = []struct {
+	name  string
+	build func(b *IPRangesBuilder)
+	want  []string
+	err   error
+}{
+	{
+		name: "add",
+		build: func(b *IPRangesBuilder) {
+			b.AddIP(net.ParseIP("172.18.0.30"))
+			b.AddRange(net.ParseIP("172.18.0.10"), net.ParseIP("172.18.0.20"))
+			b.AddIP(net.ParseIP("172.18.0.21"))
+			b.AddPrefix(netip.MustParsePrefix("172.18.1.0/24"))
+			b.AddRange(net.ParseIP("172.18.0.15"), net.ParseIP("172.18.0.25"))
+			b.AddIP(net.ParseIP("172.18.0.1"))
+		},
+		want: []string{"172.18.0.1", "172.18.0.10-172.18.0.25", "172.18.0.30", "172.18.1.0/24"},
+	},
+	{
+		name: "add bridging",
+		build: func(b *IPRangesBuilder) {
+			b.AddRange(net.ParseIP("fd00::1"), net.ParseIP("fd00::3"))
+			b.AddRange(net.ParseIP("fd00::7"), net.ParseIP("fd00::9"))
+			b.AddRange(net.ParseIP("fd00::b"), net.ParseIP("fd00::c"))
+			b.AddRange(net.ParseIP("fd00::4"), net.ParseIP("fd00::a"))
+		},
+		want: []string{"fd00::1-fd00::c"},
+	},
+	{
+		name: "remove",
+		build: func(b *IPRangesBuilder) {
+			b.AddPrefix(netip.MustParsePrefix("172.18.0.0/24"))
+			b.AddPrefix(netip.MustParsePrefix("172.18.2.0/24"))
+			b.RemoveIP(net.ParseIP("172.18.0.0"))
+			b.RemoveRange(net.ParseIP("172.18.0.10"), net.ParseIP("172.18.0.19"))
+			b.RemovePrefix(netip.MustParsePrefix("172.18.0.128/25"))
+			b.RemoveRange(net.ParseIP("172.18.0.100"), net.ParseIP("172.18.2.127"))
+			b.RemoveIP(net.ParseIP("172.18.5.1"))
+		},
+		want: []string{"172.18.0.1-172.18.0.9", "172.18.0.20-172.18.0.99", "172.18.2.128/25"},
+	},
+	{
+		name: "set",
+		build: func(b *IPRangesBuilder) {
+			rs, _ := Parse("172.18.0.0/24", "172.18.1.0/24")
+			b.AddSet(rs)
+			rs, _ = Parse("172.18.0.128/25", "172.18.1.1-172.18.1.255")
+			b.RemoveSet(rs)
+			b.AddSet(&IPRanges{})
+		},
+		want: []string{"172.18.0.0/25", "172.18.1.0"},
+	},
+	{
+		name:  "empty",
+		build: func(b *IPRangesBuilder) {},
+		want:  []string{},
+	},
+	{
+		name: "invalid IP",
+		build: func(b *IPRangesBuilder) {
+			b.AddIP(net.ParseIP("172.18.0.1"))
+			b.AddIP(nil)
+		},
+		err: errInvalidIPRangeFormat,
+	},
+	{
+		name: "start exceeds end",
+		build: func(b *IPRangesBuilder) {
+			b.AddRange(net.ParseIP("172.18.0.10"), net.ParseIP("172.18.0.1"))
+		},
+		err: errInvalidIPRangeFormat,
+	},
+	{
+		name: "dual-stack",
+		build: func(b *IPRangesBuilder) {
+			b.AddIP(net.ParseIP("172.18.0.1"))
+			b.RemovePrefix(netip.MustParsePrefix("fd00::/64"))
+		},
+		err: errDualStackIPRanges,
+	},
+}
+
+func TestIPRangesBuilder(t *testing.T) {
+	t.Parallel()
+	for _, test := range ipRangesBuilderTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			var b IPRangesBuilder
+			test.build(&b)
+			ranges, err := b.Build()
+			if err != nil || test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("IPRangesBuilder.Build() err %v, want %v", err, test.err)
+				}
+				return
+			}
+
+			if ss := ranges.Strings(); !cmp.Equal(ss, test.want) {
+				t.Fatalf("IPRangesBuilder.Build() = %q, want %q", ss, test.want)
+			}
+			if ranges.IsOverlap() {
+				t.Fatalf("IPRangesBuilder.Build() = %v, want no overlap", ranges)
+			}
+		})
+	}
+}
+
+func TestIPRangesBuilderBuild(t *testing.T) {
+	t.Parallel()
+	var b IPRangesBuilder
+	b.AddPrefix(netip.MustParsePrefix("172.18.0.0/24"))
+	ranges, err := b.Build()
+	if err != nil {
+		t.Fatalf("IPRangesBuilder.Build() err %q", err)
+	}
+
+	b.RemoveIP(net.ParseIP("172.18.0.1"))
+	if ranges.String() != "172.18.0.0/24" {
+		t.Fatalf("IPRangesBuilder.Build() = %v after RemoveIP, want 172.18.0.0/24", ranges)
+	}
+}
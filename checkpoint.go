@@ -0,0 +1,323 @@
+package iprange
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math/big"
+)
+
+// checkpointVersion is the version of the checkpoint format, which is
+// bumped whenever the format changes incompatibly.
+const checkpointVersion = 1
+
+// The kinds of the iterators that checkpoints are taken from.
+const (
+	kindIPIterator    = 'i'
+	kindBlockIterator = 'b'
+	kindCIDRIterator  = 'c'
+)
+
+// A checkpoint is encoded in unpadded URL-safe base64 as:
+//
+//	version (1 byte) | kind (1 byte) | fingerprint (8 bytes) | position
+//
+// where fingerprint identifies the IP ranges being scanned, and position is
+// a sequence of uvarints whose layout depends on the kind of the iterator.
+
+// Checkpoint returns the position of the ipIterator as a compact token,
+// which can be persisted to resume scanning later, even in another process,
+// by Restore on an ipIterator of the same IP ranges.
+func (ii *ipIterator) Checkpoint() string {
+	b := newCheckpoint(kindIPIterator, ii.ranges)
+	b = binary.AppendUvarint(b, uint64(ii.rangeIndex))
+	if ii.rangeIndex < len(ii.ranges) {
+		if ii.current.version() == Unknown {
+			b = append(b, 0)
+		} else {
+			b = append(b, 1)
+			b = appendUint128(b, ii.current.num.sub(ii.ranges[ii.rangeIndex].start.num))
+		}
+	}
+
+	return encodeCheckpoint(b)
+}
+
+// Restore moves the ipIterator to the position of checkpoint, which must
+// have been returned by Checkpoint of an ipIterator of the same IP ranges.
+// Otherwise, the error errInvalidCheckpoint will be returned, and the
+// ipIterator is left untouched.
+func (ii *ipIterator) Restore(checkpoint string) error {
+	r, err := decodeCheckpoint(checkpoint, kindIPIterator, ii.ranges)
+	if err != nil {
+		return err
+	}
+
+	i := r.index(len(ii.ranges))
+	var current xIP
+	if i < len(ii.ranges) && r.flag() {
+		current = r.offset(ii.ranges[i])
+	}
+	if err := r.close(); err != nil {
+		return err
+	}
+
+	ii.rangeIndex = i
+	ii.current = current
+
+	return nil
+}
+
+// Checkpoint returns the position of the blockIterator as a compact token,
+// see ipIterator.Checkpoint. The token also records the block size, which
+// must not change on Restore.
+func (bi *blockIterator) Checkpoint() string {
+	b := newCheckpoint(kindBlockIterator, bi.ranges.ranges)
+	b = appendBigInt(b, bi.blockSize)
+	if bi.start == nil {
+		b = append(b, 0)
+	} else {
+		b = append(b, 1)
+		b = appendBigInt(b, bi.start)
+	}
+
+	return encodeCheckpoint(b)
+}
+
+// Restore moves the blockIterator to the position of checkpoint, see
+// ipIterator.Restore. The error errInvalidCheckpoint will also be returned
+// when the block size of checkpoint differs.
+func (bi *blockIterator) Restore(checkpoint string) error {
+	r, err := decodeCheckpoint(checkpoint, kindBlockIterator, bi.ranges.ranges)
+	if err != nil {
+		return err
+	}
+
+	blockSize := r.bigInt()
+	var start, end *big.Int
+	if r.flag() {
+		start = r.bigInt()
+	}
+	if err := r.close(); err != nil {
+		return err
+	}
+
+	if blockSize.Cmp(bi.blockSize) != 0 {
+		return fmt.Errorf("%w: block size %v, want %v", errInvalidCheckpoint, blockSize, bi.blockSize)
+	}
+	if start != nil {
+		if new(big.Int).Rem(start, blockSize).Sign() != 0 {
+			return fmt.Errorf("%w: unaligned block %v", errInvalidCheckpoint, start)
+		}
+		end = new(big.Int).Add(start, blockSize)
+		end.Sub(end, bigInt[1])
+	}
+
+	bi.start = start
+	bi.end = end
+
+	return nil
+}
+
+// Checkpoint returns the position of the cidrIterator as a compact token,
+// see ipIterator.Checkpoint. The position records the shortest prefix
+// length along with the index of the ipRange and the offset within it.
+func (ci *cidrIterator) Checkpoint() string {
+	b := newCheckpoint(kindCIDRIterator, ci.ranges)
+	b = binary.AppendUvarint(b, uint64(ci.minOnes))
+	b = binary.AppendUvarint(b, uint64(ci.rangeIndex))
+	if ci.rangeIndex < len(ci.ranges) {
+		b = appendUint128(b, ci.current.num.sub(ci.ranges[ci.rangeIndex].start.num))
+	}
+
+	return encodeCheckpoint(b)
+}
+
+// Restore moves the cidrIterator to the position of checkpoint, see
+// ipIterator.Restore. The error errInvalidCheckpoint will also be returned
+// when the shortest prefix length of checkpoint differs, see
+// BoundedCIDRIterator.
+func (ci *cidrIterator) Restore(checkpoint string) error {
+	r, err := decodeCheckpoint(checkpoint, kindCIDRIterator, ci.ranges)
+	if err != nil {
+		return err
+	}
+
+	minOnes := r.uvarint()
+	i := r.index(len(ci.ranges))
+	current := ci.current
+	if i < len(ci.ranges) {
+		current = r.offset(ci.ranges[i])
+	}
+	if err := r.close(); err != nil {
+		return err
+	}
+
+	if minOnes != uint64(ci.minOnes) {
+		return fmt.Errorf("%w: minimum prefix length %d, want %d", errInvalidCheckpoint, minOnes, ci.minOnes)
+	}
+
+	ci.rangeIndex = i
+	ci.current = current
+
+	return nil
+}
+
+// newCheckpoint returns the header of a checkpoint of kind over ranges.
+func newCheckpoint(kind byte, ranges []ipRange) []byte {
+	b := []byte{checkpointVersion, kind}
+
+	return binary.BigEndian.AppendUint64(b, fingerprint(ranges))
+}
+
+// encodeCheckpoint encodes checkpoint b as a token.
+func encodeCheckpoint(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// fingerprint returns the FNV-1a hash of ranges, which identifies them in
+// checkpoints.
+func fingerprint(ranges []ipRange) uint64 {
+	h := fnv.New64a()
+	b := make([]byte, 0, 33)
+	for _, r := range ranges {
+		b = append(b[:0], byte(r.start.version()))
+		b = binary.BigEndian.AppendUint64(b, r.start.num.hi)
+		b = binary.BigEndian.AppendUint64(b, r.start.num.lo)
+		b = binary.BigEndian.AppendUint64(b, r.end.num.hi)
+		b = binary.BigEndian.AppendUint64(b, r.end.num.lo)
+		h.Write(b)
+	}
+
+	return h.Sum64()
+}
+
+// appendUint128 appends uint128 u to checkpoint b.
+func appendUint128(b []byte, u uint128) []byte {
+	b = binary.AppendUvarint(b, u.hi)
+
+	return binary.AppendUvarint(b, u.lo)
+}
+
+// appendBigInt appends non-negative big.Int n to checkpoint b.
+func appendBigInt(b []byte, n *big.Int) []byte {
+	bs := n.Bytes()
+	b = binary.AppendUvarint(b, uint64(len(bs)))
+
+	return append(b, bs...)
+}
+
+// checkpointReader reads the position of a checkpoint. Once a read fails,
+// the following reads return zero values, and close reports the failure.
+type checkpointReader struct {
+	buf []byte
+	err error
+}
+
+// decodeCheckpoint decodes token, whose kind and IP ranges must be kind and
+// ranges, and returns a checkpointReader of its position.
+func decodeCheckpoint(token string, kind byte, ranges []ipRange) (*checkpointReader, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidCheckpoint, err)
+	}
+
+	switch {
+	case len(b) < 10:
+		return nil, fmt.Errorf("%w: too short", errInvalidCheckpoint)
+	case b[0] != checkpointVersion:
+		return nil, fmt.Errorf("%w: unsupported version %d", errInvalidCheckpoint, b[0])
+	case b[1] != kind:
+		return nil, fmt.Errorf("%w: taken from another kind of iterator", errInvalidCheckpoint)
+	case binary.BigEndian.Uint64(b[2:10]) != fingerprint(ranges):
+		return nil, fmt.Errorf("%w: taken from other IP ranges", errInvalidCheckpoint)
+	}
+
+	return &checkpointReader{buf: b[10:]}, nil
+}
+
+// uvarint reads a uint64.
+func (r *checkpointReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	x, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.err = fmt.Errorf("%w: truncated", errInvalidCheckpoint)
+		return 0
+	}
+	r.buf = r.buf[n:]
+
+	return x
+}
+
+// flag reads a boolean.
+func (r *checkpointReader) flag() bool {
+	if r.err != nil {
+		return false
+	}
+
+	if len(r.buf) == 0 || r.buf[0] > 1 {
+		r.err = fmt.Errorf("%w: bad flag", errInvalidCheckpoint)
+		return false
+	}
+	f := r.buf[0] == 1
+	r.buf = r.buf[1:]
+
+	return f
+}
+
+// index reads the index of an ipRange, which must be no more than n.
+func (r *checkpointReader) index(n int) int {
+	i := r.uvarint()
+	if i > uint64(n) {
+		if r.err == nil {
+			r.err = fmt.Errorf("%w: range index %d out of %d", errInvalidCheckpoint, i, n)
+		}
+		return n
+	}
+
+	return int(i)
+}
+
+// offset reads an offset in ipRange ir, and returns the xIP there.
+func (r *checkpointReader) offset(ir ipRange) xIP {
+	u := uint128{r.uvarint(), r.uvarint()}
+	if u.cmp(ir.end.num.sub(ir.start.num)) > 0 {
+		if r.err == nil {
+			r.err = fmt.Errorf("%w: offset out of range %s", errInvalidCheckpoint, &ir)
+		}
+		return ir.start
+	}
+
+	return ir.start.add(u)
+}
+
+// bigInt reads a non-negative big.Int.
+func (r *checkpointReader) bigInt() *big.Int {
+	n := r.uvarint()
+	if r.err != nil {
+		return new(big.Int)
+	}
+
+	if n > uint64(len(r.buf)) {
+		r.err = fmt.Errorf("%w: truncated", errInvalidCheckpoint)
+		return new(big.Int)
+	}
+	x := new(big.Int).SetBytes(r.buf[:n])
+	r.buf = r.buf[n:]
+
+	return x
+}
+
+// close returns the error of the reads, if any, or if there are bytes left
+// unread.
+func (r *checkpointReader) close() error {
+	if r.err == nil && len(r.buf) != 0 {
+		r.err = fmt.Errorf("%w: trailing bytes", errInvalidCheckpoint)
+	}
+
+	return r.err
+}
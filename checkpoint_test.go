@@ -0,0 +1,200 @@
+package iprange
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIPIteratorCheckpoint(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		ranges *IPRanges
+		steps  int
+		prev   bool
+	}{
+		{
+			name:   "start",
+			ranges: mustParse("172.18.0.1-172.18.0.3", "172.18.0.10"),
+			steps:  0,
+		},
+		{
+			name:   "within range",
+			ranges: mustParse("172.18.0.1-172.18.0.3", "172.18.0.10"),
+			steps:  2,
+		},
+		{
+			name:   "after Prev",
+			ranges: mustParse("172.18.0.1-172.18.0.3", "172.18.0.10"),
+			steps:  4,
+			prev:   true,
+		},
+		{
+			name:   "exhausted",
+			ranges: mustParse("172.18.0.1-172.18.0.3", "172.18.0.10"),
+			steps:  5,
+		},
+		{
+			name:   "IPv6",
+			ranges: mustParse("::/0"),
+			steps:  3,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			iter := test.ranges.IPIterator()
+			for i := 0; i < test.steps; i++ {
+				iter.Next()
+			}
+			if test.prev {
+				iter.Prev()
+			}
+			checkpoint := iter.Checkpoint()
+
+			restored := test.ranges.IPIterator()
+			if err := restored.Restore(checkpoint); err != nil {
+				t.Fatalf("IPRanges(%v).IPIterator().Restore(%q) error: %v", test.ranges, checkpoint, err)
+			}
+			for i := 0; i < 3; i++ {
+				if got, want := restored.Next(), iter.Next(); !cmp.Equal(got, want) {
+					t.Errorf("IPRanges(%v).IPIterator().Next() = %v after Restore, want %v", test.ranges, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBlockIteratorCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	rr := mustParse("172.18.0.0/24")
+	blockSize := big.NewInt(10)
+	iter := rr.BlockIterator(blockSize)
+	restored := rr.BlockIterator(blockSize)
+	if err := restored.Restore(iter.Checkpoint()); err != nil {
+		t.Fatalf("IPRanges(%v).BlockIterator(%v).Restore() error: %v", rr, blockSize, err)
+	}
+
+	for i := 0; i < 30; i++ {
+		if i%7 == 0 {
+			checkpoint := iter.Checkpoint()
+			restored = rr.BlockIterator(blockSize)
+			if err := restored.Restore(checkpoint); err != nil {
+				t.Fatalf("IPRanges(%v).BlockIterator(%v).Restore(%q) error: %v", rr, blockSize, checkpoint, err)
+			}
+		}
+
+		got, want := fmt.Sprint(restored.Next()), fmt.Sprint(iter.Next())
+		if got != want {
+			t.Errorf("IPRanges(%v).BlockIterator(%v).Next() = %v after Restore, want %v", rr, blockSize, got, want)
+		}
+	}
+}
+
+func TestCIDRIteratorCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	rr := mustParse("172.18.0.1-172.18.0.200", "172.18.1.3-172.18.2.9")
+	iter := rr.CIDRIterator()
+	for i := 0; i < 50; i++ {
+		checkpoint := iter.Checkpoint()
+		restored := rr.CIDRIterator()
+		if err := restored.Restore(checkpoint); err != nil {
+			t.Fatalf("IPRanges(%v).CIDRIterator().Restore(%q) error: %v", rr, checkpoint, err)
+		}
+
+		got, want := fmt.Sprint(restored.Next()), fmt.Sprint(iter.Next())
+		if got != want {
+			t.Errorf("IPRanges(%v).CIDRIterator().Next() = %v after Restore, want %v", rr, got, want)
+		}
+	}
+}
+
+func TestCheckpointRestoreInvalid(t *testing.T) {
+	t.Parallel()
+	rr := mustParse("172.18.0.1-172.18.0.3", "172.18.0.10")
+	iter := rr.IPIterator()
+	iter.Next()
+	valid := iter.Checkpoint()
+	raw, _ := base64.RawURLEncoding.DecodeString(valid)
+	tamper := func(f func(b []byte) []byte) string {
+		b := f(append([]byte(nil), raw...))
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+
+	tests := []struct {
+		name       string
+		checkpoint string
+		restore    func(checkpoint string) error
+	}{
+		{
+			name:       "garbage",
+			checkpoint: "!!!",
+			restore:    rr.IPIterator().Restore,
+		},
+		{
+			name:       "too short",
+			checkpoint: tamper(func(b []byte) []byte { return b[:5] }),
+			restore:    rr.IPIterator().Restore,
+		},
+		{
+			name:       "version",
+			checkpoint: tamper(func(b []byte) []byte { b[0] = checkpointVersion + 1; return b }),
+			restore:    rr.IPIterator().Restore,
+		},
+		{
+			name:       "other IP ranges",
+			checkpoint: valid,
+			restore:    mustParse("172.18.0.1-172.18.0.3").IPIterator().Restore,
+		},
+		{
+			name:       "other kind",
+			checkpoint: valid,
+			restore:    rr.CIDRIterator().Restore,
+		},
+		{
+			name:       "range index",
+			checkpoint: tamper(func(b []byte) []byte { b[10] = 3; return b }),
+			restore:    rr.IPIterator().Restore,
+		},
+		{
+			name:       "offset",
+			checkpoint: tamper(func(b []byte) []byte { return append(b[:12], 0, 5) }),
+			restore:    rr.IPIterator().Restore,
+		},
+		{
+			name:       "trailing bytes",
+			checkpoint: tamper(func(b []byte) []byte { return append(b, 0) }),
+			restore:    rr.IPIterator().Restore,
+		},
+		{
+			name:       "shortest prefix length",
+			checkpoint: rr.BoundedCIDRIterator(30, 32).Checkpoint(),
+			restore:    rr.CIDRIterator().Restore,
+		},
+		{
+			name:       "block size",
+			checkpoint: rr.BlockIterator(big.NewInt(2)).Checkpoint(),
+			restore:    rr.BlockIterator(big.NewInt(3)).Restore,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := test.restore(test.checkpoint); !IsInvalidCheckpoint(err) {
+				t.Errorf("Restore(%q) error = %v, want errInvalidCheckpoint", test.checkpoint, err)
+			}
+		})
+	}
+}
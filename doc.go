@@ -9,6 +9,20 @@ The following IP range formats are supported:
 	172.18.0.1-10           fd00::1-a
 	172.18.0.1-172.18.1.10  fd00::1-fd00::1:a
 
+IPv4 addresses can also be written in octet notation like nmap, where each
+octet is either a wildcard or a comma list of values and ranges, which may
+expand to many IP ranges:
+
+	10.0.*.1  10.0.1-3.1-254  192.168.0,2,4.0/24
+
+Masks are accepted as well, netmasks after a slash, and either netmasks or
+wildcard masks as in ACLs after a space, whose ones mark the bits to
+ignore. A mask of all zeros or all ones after a space is a wildcard mask,
+as in ACLs. A wildcard mask may be non-contiguous, which expands to many IP
+ranges, and WildcardMasks renders IP ranges back as such ACL entries:
+
+	10.0.0.0/255.255.255.0  10.0.0.0 255.255.0.0  10.0.0.0 0.0.0.255
+
 It takes a set of IP range strings, and returns a list of start-end IP
 address pairs, which can then be automatically extended and normalized,
 for instance:
@@ -26,9 +40,22 @@ assert the errors:
 	func IsInvalidIPRangeFormat(err error) bool
 	func IsDualStackIPRanges(err error) bool
 
+Both errors are wrapped in a *ParseError, which tells the index of the IP
+range string that fails to parse, the string itself, and the reason. Parse
+stops at the first invalid IP range string, use ParseAll to collect the
+errors of all invalid IP range strings at once.
+
+If IPv4 and IPv6 addresses really have to be kept together, parse them as
+DualStackRanges instead, which holds one IPRanges per IP version and routes
+each operation to the IPRanges with the corresponding IP version:
+
+	dual, err := iprange.ParseDualStack("172.18.0.1", "fd00::/64")  // √
+
 Use the interval methods of IPRanges to calculate the union, difference or
 intersection of two IPRanges. They do not change the original parameters
-(rr and rs), just calculate, and return the results.
+(rr and rs), just calculate, and return the results. In fact, apart from
+decoding with UnmarshalText or UnmarshalJSON, no method ever changes an
+IPRanges, so an IPRanges can be safely shared.
 
 	func (rr *IPRanges) Union(rs *IPRanges) *IPRanges
 	func (rr *IPRanges) Diff(rs *IPRanges) *IPRanges
@@ -61,6 +88,80 @@ call the method Next() until nil is returned:
 		// Do someting.
 	}
 
+An IP iterator can also move backward with Prev, or jump to an IP address
+with Seek or to an index with SeekIndex, while ReverseIPIterator scans
+from the last IP address to the first one. BoundedCIDRIterator bounds the
+prefix lengths of the CIDRs, such as to scan every /24 that covers IP
+ranges. RandomIPIterator scans each IP address exactly once in a
+pseudo-random order decided by a seed, which suits network scanners. To
+scan in parallel instead, Partition divides IP ranges into disjoint pieces
+of nearly equal sizes, one for each worker. Long scans can survive
+restarts as well: Checkpoint exports the position of an IP, block or CIDR
+iterator as a token, from which Restore resumes on an iterator of the same
+IP ranges.
+
+With Go 1.23 or later, All, Blocks and Prefixes return iterators over the
+same for range loops instead, while Ranges already returns a slice:
+
+	for ip := range ranges.All() {
+		// Do something.
+	}
+
+Code built on net/netip can use the counterparts of the above instead,
+which take or return netip.Addr and netip.Prefix:
+
+	func FromPrefixes(prefixes ...netip.Prefix) (*IPRanges, error)
+	func FromAddrRange(start, end netip.Addr) (*IPRanges, error)
+	func (rr *IPRanges) ContainsAddr(addr netip.Addr) bool
+	func (rr *IPRanges) AddrIterator() *addrIterator
+	func (rr *IPRanges) PrefixIterator() *prefixIterator
+
+An Allocator hands out IP addresses of an IPRanges pool, keeping track of
+the free and used ones as IP ranges, so that large IPv6 pools are cheap:
+
+	a := iprange.NewAllocator(pool)
+	ip, err := a.Allocate()
+
+Which free IP address to allocate is decided by a Strategy, the lowest
+one by default. LowestFree, RoundRobin, Random and Sticky are provided:
+
+	a.SetStrategy(iprange.NewRoundRobin(nil))
+	ip, err = a.AllocateWith(iprange.Sticky{Key: podID})
+
+To remember the allocated IP addresses across restarts, reserve them in a
+Store instead. A DirStore keeps one file per IP address in a directory,
+and can be shared by processes on the same host:
+
+	s, err := iprange.NewDirStore("/var/lib/networks/pool")
+	ip, err := iprange.AllocateFromStore(s, pool, nil, containerID)
+
+A LeaseAllocator allocates IP addresses of an Allocator as leases, which
+are reclaimed once they expire, unless they are renewed:
+
+	l := iprange.NewLeaseAllocator(a, nil)
+	lease, err := l.AllocateWithTTL(clientID, time.Hour)
+	lease, err = l.Renew(lease.IP, time.Hour)
+
+A PrefixAllocator hands out prefixes of an IPRanges pool instead, such as
+/24 out of a /12, splitting and coalescing them like a buddy allocator:
+
+	pa := iprange.NewPrefixAllocator(pool)
+	prefix, err := pa.AllocatePrefix(24)
+
+Several named pools that must not overlap, such as "primary" and
+"overflow", can be combined into a PoolSet, which allocates from them in
+the order of their priorities, or by their labels.
+
+A QuotaAllocator limits how many IP addresses and prefixes of a shared
+pool each owner may hold, and tells which owner holds an IP address.
+
+Both IPRanges and DualStackRanges implement encoding.TextMarshaler and
+json.Marshaler, as well as their Unmarshaler counterparts, so they can be
+embedded in configuration files directly. The text form is a comma
+separated list, and the JSON form is an array of IP range strings:
+
+	{"pool": ["172.18.0.0/24", "172.18.1.1-172.18.1.10"]}
+
 Finally, the inspiration for writing this package comes from
 
 	CNI plugins:      https://github.com/containernetworking/plugins
@@ -0,0 +1,312 @@
+package iprange
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+)
+
+// DualStackRanges is a pair of IPRanges, one per IP version, which allows
+// both IPv4 and IPv6 addresses to be held in a single set. Every operation
+// is routed to the IPRanges with the corresponding IP version, and the IPv4
+// part always comes before the IPv6 part when the set is iterated over.
+//
+// The zero value of DualStackRanges is an empty set.
+type DualStackRanges struct {
+	v4 *IPRanges
+	v6 *IPRanges
+}
+
+// ParseDualStack parses a set of IP range format strings as DualStackRanges.
+// Unlike Parse, the strings may contain both IPv4 and IPv6 addresses, which
+// are split into two family-homogeneous IPRanges.
+//
+// The error errInvalidIPRangeFormat will be returned in a *ParseError when
+// one of IP range string is invalid.
+func ParseDualStack(rs ...string) (*DualStackRanges, error) {
+	v4 := &IPRanges{version: IPv4}
+	v6 := &IPRanges{version: IPv6}
+	for i, r := range rs {
+		vs, err := parse(r)
+		if err != nil {
+			err.Index = i
+			return nil, err
+		}
+
+		for _, v := range vs {
+			if v.start.version() == IPv4 {
+				v4.ranges = append(v4.ranges, v)
+			} else {
+				v6.ranges = append(v6.ranges, v)
+			}
+		}
+	}
+
+	return &DualStackRanges{
+		v4: v4,
+		v6: v6,
+	}, nil
+}
+
+// IPv4 returns the IPv4 part of DualStackRanges ds.
+func (ds *DualStackRanges) IPv4() *IPRanges {
+	if ds.v4 == nil {
+		return &IPRanges{version: IPv4}
+	}
+
+	return ds.v4
+}
+
+// IPv6 returns the IPv6 part of DualStackRanges ds.
+func (ds *DualStackRanges) IPv6() *IPRanges {
+	if ds.v6 == nil {
+		return &IPRanges{version: IPv6}
+	}
+
+	return ds.v6
+}
+
+// Contains reports whether DualStackRanges ds contain net.IP ip.
+func (ds *DualStackRanges) Contains(ip net.IP) bool {
+	w := ipToXIP(ip)
+	switch w.version() {
+	case IPv4:
+		return ds.IPv4().contains(w)
+	case IPv6:
+		return ds.IPv6().contains(w)
+	default:
+		return false
+	}
+}
+
+// Equal reports whether DualStackRanges ds is equal to ds2.
+func (ds *DualStackRanges) Equal(ds2 *DualStackRanges) bool {
+	return ds.IPv4().Equal(ds2.IPv4()) && ds.IPv6().Equal(ds2.IPv6())
+}
+
+// MergeEqual reports whether DualStackRanges ds is equal to ds2 after both
+// of them are merged.
+func (ds *DualStackRanges) MergeEqual(ds2 *DualStackRanges) bool {
+	return ds.IPv4().MergeEqual(ds2.IPv4()) && ds.IPv6().MergeEqual(ds2.IPv6())
+}
+
+// Size calculates the total number of IP addresses that pertain to
+// DualStackRanges ds.
+func (ds *DualStackRanges) Size() *big.Int {
+	n := ds.IPv4().Size()
+
+	return n.Add(n, ds.IPv6().Size())
+}
+
+// Merge merges the IPv4 and IPv6 parts of DualStackRanges ds respectively.
+func (ds *DualStackRanges) Merge() *DualStackRanges {
+	return &DualStackRanges{
+		v4: ds.IPv4().Merge(),
+		v6: ds.IPv6().Merge(),
+	}
+}
+
+// IsOverlap reports whether DualStackRanges ds have overlapping parts.
+func (ds *DualStackRanges) IsOverlap() bool {
+	return ds.IPv4().IsOverlap() || ds.IPv6().IsOverlap()
+}
+
+// Union calculates the union of DualStackRanges ds and ds2 family by family.
+// The result is always merged (ordered and deduplicated).
+func (ds *DualStackRanges) Union(ds2 *DualStackRanges) *DualStackRanges {
+	return &DualStackRanges{
+		v4: ds.IPv4().Union(ds2.IPv4()),
+		v6: ds.IPv6().Union(ds2.IPv6()),
+	}
+}
+
+// Diff calculates the difference of DualStackRanges ds and ds2 family by
+// family. The result is always merged (ordered and deduplicated).
+func (ds *DualStackRanges) Diff(ds2 *DualStackRanges) *DualStackRanges {
+	return &DualStackRanges{
+		v4: ds.IPv4().Diff(ds2.IPv4()),
+		v6: ds.IPv6().Diff(ds2.IPv6()),
+	}
+}
+
+// Intersect calculates the intersection of DualStackRanges ds and ds2
+// family by family. The result is always merged (ordered and deduplicated).
+func (ds *DualStackRanges) Intersect(ds2 *DualStackRanges) *DualStackRanges {
+	return &DualStackRanges{
+		v4: ds.IPv4().Intersect(ds2.IPv4()),
+		v6: ds.IPv6().Intersect(ds2.IPv6()),
+	}
+}
+
+// Slice returns a slice of DualStackRanges, supporting negative indexes.
+// The IPv4 part is indexed before the IPv6 part.
+func (ds *DualStackRanges) Slice(start, end *big.Int) *DualStackRanges {
+	v4, v6 := ds.IPv4(), ds.IPv6()
+	rs := &DualStackRanges{
+		v4: &IPRanges{version: IPv4},
+		v6: &IPRanges{version: IPv6},
+	}
+
+	size := ds.Size()
+	if size.Sign() == 0 {
+		return rs
+	}
+
+	if start.Sign() < 0 {
+		start = new(big.Int).Add(start, size)
+		if start.Sign() < 0 {
+			start = big.NewInt(0)
+		}
+	}
+
+	if end.Sign() < 0 {
+		end = new(big.Int).Add(end, size)
+		if end.Sign() < 0 {
+			return rs
+		}
+	}
+
+	if start.Cmp(end) > 0 {
+		return rs
+	}
+
+	v4Size := v4.Size()
+	if start.Cmp(v4Size) < 0 {
+		rs.v4 = v4.Slice(start, end)
+	}
+
+	if end.Cmp(v4Size) >= 0 {
+		start = new(big.Int).Sub(start, v4Size)
+		if start.Sign() < 0 {
+			start = big.NewInt(0)
+		}
+		rs.v6 = v6.Slice(start, new(big.Int).Sub(end, v4Size))
+	}
+
+	return rs
+}
+
+// IPIterator generates a new iterator for scanning IP addresses, IPv4
+// addresses first and IPv6 addresses after.
+func (ds *DualStackRanges) IPIterator() *ipIterator {
+	return &ipIterator{
+		ranges: ds.ranges(),
+	}
+}
+
+// ReverseIPIterator generates a new iterator for scanning IP addresses
+// backward, IPv6 addresses first and IPv4 addresses after.
+func (ds *DualStackRanges) ReverseIPIterator() *reverseIPIterator {
+	return newReverseIPIterator(ds.ranges())
+}
+
+// BlockIterator generates a new iterator for scanning IP blocks. blockSize
+// should be at least 1. A block may contain both IPv4 and IPv6 addresses
+// when it straddles the two parts of DualStackRanges ds.
+func (ds *DualStackRanges) BlockIterator(blockSize *big.Int) *dualStackBlockIterator {
+	if blockSize == nil || blockSize.Sign() <= 0 {
+		blockSize = big.NewInt(1)
+	}
+
+	return &dualStackBlockIterator{
+		ranges:    ds,
+		size:      ds.Size(),
+		blockSize: blockSize,
+	}
+}
+
+// CIDRIterator generates a new iterator for scanning CIDR, IPv4 CIDR first
+// and IPv6 CIDR after.
+func (ds *DualStackRanges) CIDRIterator() *cidrIterator {
+	return newCIDRIterator(ds.ranges())
+}
+
+// ranges returns the ipRanges of both IP versions, IPv4 ones first.
+func (ds *DualStackRanges) ranges() []ipRange {
+	v4, v6 := ds.IPv4().ranges, ds.IPv6().ranges
+	ranges := make([]ipRange, 0, len(v4)+len(v6))
+	ranges = append(ranges, v4...)
+
+	return append(ranges, v6...)
+}
+
+// String implements fmt.Stringer.
+func (ds *DualStackRanges) String() string {
+	ss := ds.Strings()
+	if len(ss) == 1 {
+		return ss[0]
+	}
+
+	return fmt.Sprint(ss)
+}
+
+// Strings returns a slice of the string representations of the
+// DualStackRanges ds, IPv4 ones first.
+func (ds *DualStackRanges) Strings() []string {
+	return append(ds.IPv4().Strings(), ds.IPv6().Strings()...)
+}
+
+type dualStackBlockIterator struct {
+	ranges    *DualStackRanges
+	size      *big.Int
+	blockSize *big.Int
+	start     *big.Int
+	end       *big.Int
+}
+
+// Next returns the next IP block. If the dualStackBlockIterator has been
+// exhausted, return nil.
+func (bi *dualStackBlockIterator) Next() *DualStackRanges {
+	if bi.size.Sign() == 0 {
+		return nil
+	}
+
+	if bi.start == nil {
+		bi.start = big.NewInt(0)
+		bi.end = new(big.Int).Sub(bi.blockSize, bigInt[1])
+		return bi.ranges.Slice(bi.start, bi.end)
+	}
+
+	bi.start.Add(bi.start, bi.blockSize)
+	if bi.start.Cmp(bi.size) >= 0 {
+		return nil
+	}
+	bi.end.Add(bi.end, bi.blockSize)
+
+	return bi.ranges.Slice(bi.start, bi.end)
+}
+
+// NextN returns the next nth IP block. If the dualStackBlockIterator has
+// been exhausted, return nil.  If n <= 0, it is equivalent to NextN(1).
+func (bi *dualStackBlockIterator) NextN(n *big.Int) *DualStackRanges {
+	if bi.size.Sign() == 0 {
+		return nil
+	}
+
+	if n.Sign() <= 0 {
+		n = big.NewInt(1)
+	}
+
+	if bi.start == nil {
+		n = new(big.Int).Sub(n, bigInt[1])
+		bi.start = new(big.Int).Mul(n, bi.blockSize)
+		bi.end = new(big.Int).Add(bi.start, bi.blockSize)
+		bi.end.Sub(bi.end, bigInt[1])
+		return bi.ranges.Slice(bi.start, bi.end)
+	}
+
+	step := new(big.Int).Mul(n, bi.blockSize)
+	bi.start.Add(bi.start, step)
+	if bi.start.Cmp(bi.size) >= 0 {
+		return nil
+	}
+	bi.end.Add(bi.end, step)
+
+	return bi.ranges.Slice(bi.start, bi.end)
+}
+
+// Reset resets IP block iterator.
+func (bi *dualStackBlockIterator) Reset() {
+	bi.start = nil
+	bi.end = nil
+}
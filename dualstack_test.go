@@ -0,0 +1,269 @@
+package iprange
+
+import (
+	"errors"
+	"math/big"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var parseDualStackTests = []struct {
+	name string
+	rs   []string
+	want *DualStackRanges
+	err  error
+}{
+	{
+		name: "dual-stack",
+		rs: []string{
+			"fd00::1-a",
+			"172.18.0.0/24",
+			"172.18.1.1",
+		},
+		want: &DualStackRanges{
+			v4: &IPRanges{
+				version: IPv4,
+				ranges: []ipRange{
+					{
+						start: ipToXIP(net.IPv4(172, 18, 0, 0).To4()),
+						end:   ipToXIP(net.IPv4(172, 18, 0, 255).To4()),
+					},
+					{
+						start: ipToXIP(net.IPv4(172, 18, 1, 1).To4()),
+						end:   ipToXIP(net.IPv4(172, 18, 1, 1).To4()),
+					},
+				},
+			},
+			v6: &IPRanges{
+				version: IPv6,
+				ranges: []ipRange{
+					{
+						start: ipToXIP(net.ParseIP("fd00::1")),
+						end:   ipToXIP(net.ParseIP("fd00::a")),
+					},
+				},
+			},
+		},
+		err: nil,
+	},
+	{
+		name: "IPv4 only",
+		rs:   []string{"172.18.0.1"},
+		want: &DualStackRanges{
+			v4: &IPRanges{
+				version: IPv4,
+				ranges: []ipRange{
+					{
+						start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+						end:   ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					},
+				},
+			},
+		},
+		err: nil,
+	},
+	{"empty", []string{}, &DualStackRanges{}, nil},
+	{"invalid", []string{"172.18.0.1", "fd00::x"}, nil, errInvalidIPRangeFormat},
+}
+
+func TestParseDualStack(t *testing.T) {
+	t.Parallel()
+	for _, test := range parseDualStackTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ranges, err := ParseDualStack(test.rs...)
+			if err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("ParseDualStack(%q) err %q, want %q", test.rs, err, test.err)
+				}
+				return
+			}
+			if !cmp.Equal(ranges, test.want) {
+				t.Fatalf("ParseDualStack(%q) = %v, want %v", test.rs, ranges, test.want)
+			}
+		})
+	}
+}
+
+var dualStackRangesContainsTests = []struct {
+	name string
+	rs   []string
+	ip   net.IP
+	want bool
+}{
+	{"IPv4 contain", []string{"172.18.0.0/24", "fd00::/64"}, net.ParseIP("172.18.0.1"), true},
+	{"IPv6 contain", []string{"172.18.0.0/24", "fd00::/64"}, net.ParseIP("fd00::1"), true},
+	{"IPv4 not contain", []string{"fd00::/64"}, net.ParseIP("172.18.0.1"), false},
+	{"IPv6 not contain", []string{"172.18.0.0/24"}, net.ParseIP("fd00::1"), false},
+	{"invalid IP", []string{"172.18.0.0/24", "fd00::/64"}, nil, false},
+}
+
+func TestDualStackRangesContains(t *testing.T) {
+	t.Parallel()
+	for _, test := range dualStackRangesContainsTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ranges, err := ParseDualStack(test.rs...)
+			if err != nil {
+				t.Fatalf("ParseDualStack(%q) err %q", test.rs, err)
+			}
+			contains := ranges.Contains(test.ip)
+			if contains != test.want {
+				t.Fatalf("DualStackRanges(%v).Contains(%v) = %v, want %v", ranges, test.ip, contains, test.want)
+			}
+		})
+	}
+}
+
+var dualStackRangesIntervalTests = []struct {
+	name      string
+	rsX       []string
+	rsY       []string
+	union     []string
+	diff      []string
+	intersect []string
+}{
+	{
+		name:      "dual-stack",
+		rsX:       []string{"172.18.0.1-20", "fd00::1-20"},
+		rsY:       []string{"172.18.0.11-30", "fd00::11-30"},
+		union:     []string{"172.18.0.1-172.18.0.30", "fd00::1-fd00::30"},
+		diff:      []string{"172.18.0.1-172.18.0.10", "fd00::1-fd00::10"},
+		intersect: []string{"172.18.0.11-172.18.0.20", "fd00::11-fd00::20"},
+	},
+	{
+		name:      "one family each",
+		rsX:       []string{"172.18.0.0/24"},
+		rsY:       []string{"fd00::/64"},
+		union:     []string{"172.18.0.0/24", "fd00::/64"},
+		diff:      []string{"172.18.0.0/24"},
+		intersect: []string{},
+	},
+}
+
+func TestDualStackRangesInterval(t *testing.T) {
+	t.Parallel()
+	for _, test := range dualStackRangesIntervalTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			parse := func(rs []string) *DualStackRanges {
+				ranges, err := ParseDualStack(rs...)
+				if err != nil {
+					t.Fatalf("ParseDualStack(%q) err %q", rs, err)
+				}
+				return ranges
+			}
+
+			union := parse(test.rsX).Union(parse(test.rsY)).Strings()
+			if !cmp.Equal(union, test.union) {
+				t.Fatalf("DualStackRanges(%q).Union(%q) = %q, want %q", test.rsX, test.rsY, union, test.union)
+			}
+			diff := parse(test.rsX).Diff(parse(test.rsY)).Strings()
+			if !cmp.Equal(diff, test.diff) {
+				t.Fatalf("DualStackRanges(%q).Diff(%q) = %q, want %q", test.rsX, test.rsY, diff, test.diff)
+			}
+			intersect := parse(test.rsX).Intersect(parse(test.rsY)).Strings()
+			if !cmp.Equal(intersect, test.intersect) {
+				t.Fatalf("DualStackRanges(%q).Intersect(%q) = %q, want %q", test.rsX, test.rsY, intersect, test.intersect)
+			}
+		})
+	}
+}
+
+var dualStackRangesSliceTests = []struct {
+	name  string
+	rs    []string
+	start *big.Int
+	end   *big.Int
+	want  []string
+}{
+	{"IPv4 part", []string{"172.18.0.0-3", "fd00::0-3"}, big.NewInt(1), big.NewInt(2), []string{"172.18.0.1-172.18.0.2"}},
+	{"IPv6 part", []string{"172.18.0.0-3", "fd00::0-3"}, big.NewInt(4), big.NewInt(5), []string{"fd00::/127"}},
+	{"straddle", []string{"172.18.0.0-3", "fd00::0-3"}, big.NewInt(2), big.NewInt(-3), []string{"172.18.0.2/31", "fd00::/127"}},
+	{"negative", []string{"172.18.0.0-3", "fd00::0-3"}, big.NewInt(-1), big.NewInt(-1), []string{"fd00::3"}},
+	{"start exceeds end", []string{"172.18.0.0-3", "fd00::0-3"}, big.NewInt(3), big.NewInt(2), []string{}},
+	{"zero", []string{}, big.NewInt(0), big.NewInt(1), []string{}},
+}
+
+func TestDualStackRangesSlice(t *testing.T) {
+	t.Parallel()
+	for _, test := range dualStackRangesSliceTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ranges, err := ParseDualStack(test.rs...)
+			if err != nil {
+				t.Fatalf("ParseDualStack(%q) err %q", test.rs, err)
+			}
+			s := ranges.Slice(test.start, test.end).Strings()
+			if !cmp.Equal(s, test.want) {
+				t.Fatalf("DualStackRanges(%v).Slice(%v, %v) = %q, want %q", ranges, test.start, test.end, s, test.want)
+			}
+		})
+	}
+}
+
+func TestDualStackRangesIterator(t *testing.T) {
+	t.Parallel()
+	ranges, err := ParseDualStack("fd00::1-2", "172.18.0.1-2")
+	if err != nil {
+		t.Fatalf("ParseDualStack() err %q", err)
+	}
+
+	var ips []net.IP
+	ipIter := ranges.IPIterator()
+	for {
+		ip := ipIter.Next()
+		if ip == nil {
+			break
+		}
+		ips = append(ips, ip)
+	}
+	wantIPs := []net.IP{
+		net.IPv4(172, 18, 0, 1).To4(),
+		net.IPv4(172, 18, 0, 2).To4(),
+		net.ParseIP("fd00::1"),
+		net.ParseIP("fd00::2"),
+	}
+	if !cmp.Equal(ips, wantIPs) {
+		t.Fatalf("DualStackRanges(%v).IPIterator() = %v, want %v", ranges, ips, wantIPs)
+	}
+
+	var blocks []string
+	blockIter := ranges.BlockIterator(big.NewInt(3))
+	for {
+		block := blockIter.Next()
+		if block == nil {
+			break
+		}
+		blocks = append(blocks, block.String())
+	}
+	wantBlocks := []string{"[172.18.0.1-172.18.0.2 fd00::1]", "fd00::2"}
+	if !cmp.Equal(blocks, wantBlocks) {
+		t.Fatalf("DualStackRanges(%v).BlockIterator(3) = %q, want %q", ranges, blocks, wantBlocks)
+	}
+
+	var cidrs []*net.IPNet
+	cidrIter := ranges.CIDRIterator()
+	for {
+		cidr := cidrIter.Next()
+		if cidr == nil {
+			break
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	wantCIDRs := []*net.IPNet{
+		{IP: net.IPv4(172, 18, 0, 1).To4(), Mask: net.CIDRMask(32, 32)},
+		{IP: net.IPv4(172, 18, 0, 2).To4(), Mask: net.CIDRMask(32, 32)},
+		{IP: net.ParseIP("fd00::1"), Mask: net.CIDRMask(128, 128)},
+		{IP: net.ParseIP("fd00::2"), Mask: net.CIDRMask(128, 128)},
+	}
+	if !cmp.Equal(cidrs, wantCIDRs) {
+		t.Fatalf("DualStackRanges(%v).CIDRIterator() = %v, want %v", ranges, cidrs, wantCIDRs)
+	}
+}
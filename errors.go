@@ -1,6 +1,9 @@
 package iprange
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	// The string is not a valid IP range format. It occurs when parsing an
@@ -10,8 +13,120 @@ var (
 	// Dual-stack IP ranges are not allowed. It occurs when parsing a set of
 	// IP range strings, where there are both IPv4 and IPv6 addresses.
 	errDualStackIPRanges = errors.New("dual-stack IP ranges")
+
+	// The pool has no free IP address left. It occurs when allocating from
+	// a pool whose IP addresses are all allocated.
+	errPoolExhausted = errors.New("pool exhausted")
+
+	// The IP address does not pertain to the pool. It occurs when
+	// allocating or releasing an IP address outside of the pool.
+	errNotInPool = errors.New("not in pool")
+
+	// The IP address has been allocated. It occurs when allocating a
+	// specific IP address which is already in use.
+	errAllocated = errors.New("already allocated")
+
+	// The IP address has not been allocated. It occurs when releasing an
+	// IP address which is not in use.
+	errNotAllocated = errors.New("not allocated")
+
+	// The pools overlap with each other. It occurs when creating a PoolSet
+	// from pools which share IP addresses.
+	errPoolOverlap = errors.New("overlapping pools")
+
+	// The name of the pool is taken by another pool. It occurs when
+	// creating a PoolSet from pools with the same name.
+	errDuplicatePool = errors.New("duplicate pool name")
+
+	// The pool has no IP ranges. It occurs when creating a PoolSet from a
+	// pool whose Ranges is nil.
+	errEmptyPool = errors.New("pool without IP ranges")
+
+	// The owner holds as many allocations as its quota allows. It occurs
+	// when allocating for such an owner, see QuotaExceededError.
+	errQuotaExceeded = errors.New("quota exceeded")
+
+	// The checkpoint of an iterator is malformed, or was taken from
+	// another iterator. It occurs when restoring an iterator from such a
+	// checkpoint.
+	errInvalidCheckpoint = errors.New("invalid checkpoint")
+)
+
+// ParseErrorReason tells why an IP range string fails to parse, see
+// ParseError.
+type ParseErrorReason string
+
+// The reasons why an IP range string fails to parse.
+const (
+	ReasonEmpty           ParseErrorReason = "empty string"
+	ReasonBadIP           ParseErrorReason = "bad IP"
+	ReasonBadStartIP      ParseErrorReason = "bad start IP"
+	ReasonBadEndIP        ParseErrorReason = "bad end IP"
+	ReasonReversedRange   ParseErrorReason = "reversed range"
+	ReasonBadPrefixLength ParseErrorReason = "bad prefix length"
+	ReasonFamilyMismatch  ParseErrorReason = "family mismatch"
+	ReasonTooManyRanges   ParseErrorReason = "too many ranges"
+	ReasonBadMask         ParseErrorReason = "bad mask"
 )
 
+// ParseError describes an IP range string that fails to parse. It wraps
+// either errInvalidIPRangeFormat or errDualStackIPRanges, so that
+// IsInvalidIPRangeFormat and IsDualStackIPRanges still work on it.
+type ParseError struct {
+	// Index is the index of Input among the IP range strings being parsed.
+	Index int
+
+	// Input is the IP range string that fails to parse.
+	Input string
+
+	// Reason tells why Input fails to parse, which is one of the Reason
+	// constants, such as ReasonBadIP.
+	Reason ParseErrorReason
+
+	err error
+}
+
+// newParseError returns a ParseError of IP range string r, which wraps the
+// error errInvalidIPRangeFormat.
+func newParseError(r string, reason ParseErrorReason) *ParseError {
+	return &ParseError{
+		Input:  r,
+		Reason: reason,
+		err:    errInvalidIPRangeFormat,
+	}
+}
+
+// Error implements error.
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%v: %q (index %d): %s", e.err, e.Input, e.Index, e.Reason)
+}
+
+// Unwrap returns the error wrapped by ParseError e.
+func (e *ParseError) Unwrap() error {
+	return e.err
+}
+
+// QuotaExceededError describes an allocation refused because its owner
+// holds as many allocations as its quota allows. It wraps the error
+// errQuotaExceeded, so that IsQuotaExceeded works on it.
+type QuotaExceededError struct {
+	// Owner is the owner of the allocation refused.
+	Owner string
+
+	// Quota is the maximum number of allocations Owner may hold.
+	Quota int
+}
+
+// Error implements error.
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%v: %q holds %d allocations", errQuotaExceeded, e.Owner, e.Quota)
+}
+
+// Unwrap returns the error wrapped by QuotaExceededError e.
+func (e *QuotaExceededError) Unwrap() error {
+	return errQuotaExceeded
+}
+
 // IsInvalidIPRangeFormat asserts whether the err is errInvalidIPRangeFormat.
 func IsInvalidIPRangeFormat(err error) bool {
 	return errors.Is(err, errInvalidIPRangeFormat)
@@ -21,3 +136,48 @@ func IsInvalidIPRangeFormat(err error) bool {
 func IsDualStackIPRanges(err error) bool {
 	return errors.Is(err, errDualStackIPRanges)
 }
+
+// IsPoolExhausted asserts whether the err is errPoolExhausted.
+func IsPoolExhausted(err error) bool {
+	return errors.Is(err, errPoolExhausted)
+}
+
+// IsNotInPool asserts whether the err is errNotInPool.
+func IsNotInPool(err error) bool {
+	return errors.Is(err, errNotInPool)
+}
+
+// IsAllocated asserts whether the err is errAllocated.
+func IsAllocated(err error) bool {
+	return errors.Is(err, errAllocated)
+}
+
+// IsNotAllocated asserts whether the err is errNotAllocated.
+func IsNotAllocated(err error) bool {
+	return errors.Is(err, errNotAllocated)
+}
+
+// IsPoolOverlap asserts whether the err is errPoolOverlap.
+func IsPoolOverlap(err error) bool {
+	return errors.Is(err, errPoolOverlap)
+}
+
+// IsDuplicatePool asserts whether the err is errDuplicatePool.
+func IsDuplicatePool(err error) bool {
+	return errors.Is(err, errDuplicatePool)
+}
+
+// IsEmptyPool asserts whether the err is errEmptyPool.
+func IsEmptyPool(err error) bool {
+	return errors.Is(err, errEmptyPool)
+}
+
+// IsQuotaExceeded asserts whether the err is errQuotaExceeded.
+func IsQuotaExceeded(err error) bool {
+	return errors.Is(err, errQuotaExceeded)
+}
+
+// IsInvalidCheckpoint asserts whether the err is errInvalidCheckpoint.
+func IsInvalidCheckpoint(err error) bool {
+	return errors.Is(err, errInvalidCheckpoint)
+}
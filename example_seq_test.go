@@ -0,0 +1,32 @@
+//go:build go1.23
+
+package iprange_test
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/iiiceoo/iprange"
+)
+
+func ExampleIPRanges_All() {
+	ranges, err := iprange.Parse("172.18.0.1-3", "172.18.0.10")
+	if err != nil {
+		log.Fatalf("error parsing IP ranges: %v", err)
+	}
+
+	for ip := range ranges.All() {
+		fmt.Println(ip)
+	}
+	for cidr := range ranges.Prefixes() {
+		fmt.Println(cidr)
+	}
+	// Output:
+	// 172.18.0.1
+	// 172.18.0.2
+	// 172.18.0.3
+	// 172.18.0.10
+	// 172.18.0.1/32
+	// 172.18.0.2/31
+	// 172.18.0.10/32
+}
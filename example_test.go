@@ -1,10 +1,13 @@
 package iprange_test
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
 	"net"
+	"net/netip"
 
 	"github.com/iiiceoo/iprange"
 )
@@ -180,6 +183,21 @@ func ExampleIPRanges_Slice() {
 	// [172.18.0.2/31 172.18.0.10-172.18.0.13]
 }
 
+func ExampleIPRanges_Partition() {
+	ranges, err := iprange.Parse("172.18.0.0-9", "172.18.1.0/24")
+	if err != nil {
+		log.Fatalf("error parsing IP ranges: %v", err)
+	}
+
+	for _, part := range ranges.Partition(3) {
+		fmt.Println(part, part.Size())
+	}
+	// Output:
+	// [172.18.0.0-172.18.0.9 172.18.1.0-172.18.1.77] 88
+	// 172.18.1.78-172.18.1.166 89
+	// 172.18.1.167-172.18.1.255 89
+}
+
 func ExampleIPRanges_IsOverlap() {
 	ranges1, err := iprange.Parse("172.18.0.20-30", "172.18.0.25")
 	if err != nil {
@@ -280,3 +298,220 @@ func ExampleIPRanges_CIDRIterator() {
 	// 172.18.0.1/32
 	// 172.18.0.2/31
 }
+
+func ExampleParseDualStack() {
+	ranges, err := iprange.ParseDualStack("fd00::/64", "172.18.0.0/24", "fd01::1")
+	if err != nil {
+		log.Fatalf("error parsing IP ranges: %v", err)
+	}
+
+	fmt.Println(ranges)
+	fmt.Println(ranges.IPv4())
+	fmt.Println(ranges.IPv6())
+	fmt.Println(ranges.Contains(net.ParseIP("172.18.0.1")))
+	fmt.Println(ranges.Contains(net.ParseIP("fd00::1")))
+	// Output:
+	// [172.18.0.0/24 fd00::/64 fd01::1]
+	// 172.18.0.0/24
+	// [fd00::/64 fd01::1]
+	// true
+	// true
+}
+
+func ExampleFromPrefixes() {
+	ranges, err := iprange.FromPrefixes(
+		netip.MustParsePrefix("172.18.0.0/24"),
+		netip.MustParsePrefix("172.18.1.0/24"),
+	)
+	if err != nil {
+		log.Fatalf("error converting prefixes: %v", err)
+	}
+
+	fmt.Println(ranges.Merge())
+	fmt.Println(ranges.ContainsAddr(netip.MustParseAddr("172.18.1.1")))
+	// Output:
+	// 172.18.0.0/23
+	// true
+}
+
+func ExampleIPRanges_Filter() {
+	ranges, err := iprange.Parse("172.18.0.0/24", "172.18.2.0/24")
+	if err != nil {
+		log.Fatalf("error parsing IP ranges: %v", err)
+	}
+
+	ips := []net.IP{
+		net.ParseIP("172.18.0.1"),
+		net.ParseIP("172.18.1.1"),
+		net.ParseIP("172.18.2.1"),
+	}
+	fmt.Println(ranges.Merge().Filter(ips))
+	// Output:
+	// [172.18.0.1 172.18.2.1]
+}
+
+func ExampleIPRanges_Ranges() {
+	ranges, err := iprange.Parse("172.18.0.0/24", "172.18.1.1-3")
+	if err != nil {
+		log.Fatalf("error parsing IP ranges: %v", err)
+	}
+
+	for _, r := range ranges.Ranges() {
+		fmt.Println(r.Start(), r.End(), r.Size(), r.Prefixes())
+	}
+	// Output:
+	// 172.18.0.0 172.18.0.255 256 [172.18.0.0/24]
+	// 172.18.1.1 172.18.1.3 3 [172.18.1.1/32 172.18.1.2/31]
+}
+
+func ExampleIPRangesBuilder() {
+	var b iprange.IPRangesBuilder
+	b.AddPrefix(netip.MustParsePrefix("172.18.0.0/24"))
+	b.AddRange(net.ParseIP("172.18.1.0"), net.ParseIP("172.18.1.9"))
+	b.RemoveIP(net.ParseIP("172.18.0.0"))
+
+	ranges, err := b.Build()
+	if err != nil {
+		log.Fatalf("error building IP ranges: %v", err)
+	}
+
+	fmt.Println(ranges)
+	// Output:
+	// 172.18.0.1-172.18.1.9
+}
+
+func ExampleParseAll() {
+	_, err := iprange.ParseAll("172.18.0.1", "172.18.0.10-1", "fd00::1")
+	fmt.Println(err)
+	fmt.Println(iprange.IsInvalidIPRangeFormat(err), iprange.IsDualStackIPRanges(err))
+	// Output:
+	// invalid IP range format: "172.18.0.10-1" (index 1): reversed range
+	// dual-stack IP ranges: "fd00::1" (index 2): family mismatch
+	// true true
+}
+
+func ExampleParseError() {
+	_, err := iprange.Parse("172.18.0.1", "172.18.0.0/33")
+	var pe *iprange.ParseError
+	if errors.As(err, &pe) {
+		switch pe.Reason {
+		case iprange.ReasonBadPrefixLength:
+			fmt.Printf("IP range string %d has a bad prefix length\n", pe.Index)
+		default:
+			fmt.Println(pe.Reason)
+		}
+	}
+	// Output:
+	// IP range string 1 has a bad prefix length
+}
+
+func ExampleIPRanges_UnmarshalJSON() {
+	var config struct {
+		Pool *iprange.IPRanges `json:"pool"`
+	}
+	data := []byte(`{"pool": ["172.18.0.0/24", "172.18.1.1-10"]}`)
+	if err := json.Unmarshal(data, &config); err != nil {
+		log.Fatalf("error decoding config: %v", err)
+	}
+	fmt.Println(config.Pool.Size())
+
+	out, err := json.Marshal(config)
+	if err != nil {
+		log.Fatalf("error encoding config: %v", err)
+	}
+	fmt.Println(string(out))
+	// Output:
+	// 266
+	// {"pool":["172.18.0.0/24","172.18.1.1-172.18.1.10"]}
+}
+
+func ExampleAllocator() {
+	pool, err := iprange.Parse("172.18.0.0/30")
+	if err != nil {
+		log.Fatalf("error parsing IP ranges: %v", err)
+	}
+
+	a := iprange.NewAllocator(pool)
+	ip, _ := a.Allocate()
+	fmt.Println(ip)
+	if err := a.AllocateSpecific(net.ParseIP("172.18.0.3")); err != nil {
+		log.Fatalf("error allocating IP address: %v", err)
+	}
+	err = a.AllocateSpecific(net.ParseIP("172.18.0.3"))
+	fmt.Println(iprange.IsAllocated(err))
+	fmt.Println(a.Allocated(), a.Free(), a.Used())
+	// Output:
+	// 172.18.0.0
+	// true
+	// [172.18.0.0 172.18.0.3] 2 2
+}
+
+func ExampleSticky() {
+	pool, err := iprange.Parse("172.18.0.0/24")
+	if err != nil {
+		log.Fatalf("error parsing IP ranges: %v", err)
+	}
+
+	a := iprange.NewAllocator(pool)
+	ip, _ := a.AllocateWith(iprange.Sticky{Key: "pod-1"})
+	_ = a.Release(ip)
+	ip2, _ := a.AllocateWith(iprange.Sticky{Key: "pod-1"})
+	fmt.Println(ip.Equal(ip2))
+	// Output:
+	// true
+}
+
+func ExamplePrefixAllocator() {
+	pool, err := iprange.Parse("10.0.0.0/22")
+	if err != nil {
+		log.Fatalf("error parsing IP ranges: %v", err)
+	}
+
+	pa := iprange.NewPrefixAllocator(pool)
+	p1, _ := pa.AllocatePrefix(24)
+	p2, _ := pa.AllocatePrefix(26)
+	fmt.Println(p1, p2, pa.LargestFreePrefix())
+
+	_ = pa.ReleasePrefix(p1)
+	_ = pa.ReleasePrefix(p2)
+	fmt.Println(pa.LargestFreePrefix())
+	// Output:
+	// 10.0.0.0/24 10.0.1.0/26 23
+	// 22
+}
+
+func ExampleReconcile() {
+	recorded := []iprange.Reservation{
+		{IP: net.ParseIP("172.18.0.1"), Owner: "container-1"},
+		{IP: net.ParseIP("172.18.0.2"), Owner: "container-2"},
+	}
+	observed, err := iprange.Parse("172.18.0.2-3")
+	if err != nil {
+		log.Fatalf("error parsing IP ranges: %v", err)
+	}
+
+	report, err := iprange.Reconcile(recorded, observed)
+	if err != nil {
+		log.Fatalf("error reconciling: %v", err)
+	}
+	fmt.Println(report.Leaked, report.LeakedOwners["container-1"])
+	fmt.Println(report.Unknown)
+	// Output:
+	// 172.18.0.1 172.18.0.1
+	// 172.18.0.3
+}
+
+func ExampleIPRanges_WildcardMasks() {
+	ranges, err := iprange.Parse("10.0.0.0 0.0.2.255", "10.0.4.1")
+	if err != nil {
+		log.Fatalf("error parsing IP ranges: %v", err)
+	}
+
+	for _, entry := range ranges.WildcardMasks() {
+		fmt.Println(entry)
+	}
+	// Output:
+	// 10.0.0.0 0.0.0.255
+	// 10.0.2.0 0.0.0.255
+	// 10.0.4.1 0.0.0.0
+}
@@ -0,0 +1,20 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package iprange
+
+import "os"
+
+// lockFile is a no-op on platforms without file locks.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// unlockFile is a no-op on platforms without file locks.
+func unlockFile(f *os.File) error {
+	return nil
+}
+
+// syncDir is a no-op on platforms where directories cannot be synced.
+func syncDir(dir string) error {
+	return nil
+}
@@ -1,8 +1,10 @@
 package iprange
 
 import (
+	"encoding/binary"
 	"math/big"
 	"net"
+	"net/netip"
 )
 
 var bigInt = [...]*big.Int{
@@ -10,9 +12,97 @@ var bigInt = [...]*big.Int{
 	big.NewInt(1),
 }
 
-// xIP wraps net.IP in order to expand the method of net.IP.
+// xIP represents an IP address as a fixed-width 128-bit integer, so that
+// comparing and stepping IP addresses never allocate. An IPv4 address only
+// occupies the 32 least significant bits of num. The zero value of xIP is
+// not an IP, whose version is Unknown.
 type xIP struct {
-	net.IP
+	num uint128
+	ver family
+}
+
+// ipToXIP converts net.IP ip to xIP. If ip is not an IP, return the zero
+// value of xIP.
+func ipToXIP(ip net.IP) xIP {
+	nIP := normalizeIP(ip)
+	switch len(nIP) {
+	case net.IPv4len:
+		return xIP{
+			num: uint128{lo: uint64(binary.BigEndian.Uint32(nIP))},
+			ver: IPv4,
+		}
+	case net.IPv6len:
+		return xIP{
+			num: uint128{
+				hi: binary.BigEndian.Uint64(nIP[:8]),
+				lo: binary.BigEndian.Uint64(nIP[8:]),
+			},
+			ver: IPv6,
+		}
+	default:
+		return xIP{}
+	}
+}
+
+// addrToXIP converts netip.Addr addr to xIP. IPv4-mapped IPv6 addresses
+// are treated as IPv4 addresses. If addr is invalid, return the zero value
+// of xIP.
+func addrToXIP(addr netip.Addr) xIP {
+	if !addr.IsValid() {
+		return xIP{}
+	}
+
+	addr = addr.Unmap()
+	if addr.Is4() {
+		b := addr.As4()
+		return xIP{
+			num: uint128{lo: uint64(binary.BigEndian.Uint32(b[:]))},
+			ver: IPv4,
+		}
+	}
+
+	b := addr.As16()
+	return xIP{
+		num: uint128{
+			hi: binary.BigEndian.Uint64(b[:8]),
+			lo: binary.BigEndian.Uint64(b[8:]),
+		},
+		ver: IPv6,
+	}
+}
+
+// toIP converts xIP to net.IP in its normalized form, see normalizeIP.
+func (ip xIP) toIP() net.IP {
+	switch ip.ver {
+	case IPv4:
+		b := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(b, uint32(ip.num.lo))
+		return b
+	case IPv6:
+		b := make(net.IP, net.IPv6len)
+		binary.BigEndian.PutUint64(b[:8], ip.num.hi)
+		binary.BigEndian.PutUint64(b[8:], ip.num.lo)
+		return b
+	default:
+		return nil
+	}
+}
+
+// toAddr converts xIP to netip.Addr.
+func (ip xIP) toAddr() netip.Addr {
+	switch ip.ver {
+	case IPv4:
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], uint32(ip.num.lo))
+		return netip.AddrFrom4(b)
+	case IPv6:
+		var b [16]byte
+		binary.BigEndian.PutUint64(b[:8], ip.num.hi)
+		binary.BigEndian.PutUint64(b[8:], ip.num.lo)
+		return netip.AddrFrom16(b)
+	default:
+		return netip.Addr{}
+	}
 }
 
 // version returns the IP version of xIP:
@@ -21,44 +111,39 @@ type xIP struct {
 //	2: IPv6
 //	0: not an IP: Unknown
 func (ip xIP) version() family {
-	nIP := normalizeIP(ip.IP)
-	if nIP == nil {
-		return Unknown
-	}
+	return ip.ver
+}
 
-	if len(nIP) == net.IPv4len {
-		return IPv4
+// bitLen returns the length of xIP in bits, that is 32 for IPv4 and 128
+// for IPv6.
+func (ip xIP) bitLen() int {
+	if ip.ver == IPv4 {
+		return 32
 	}
 
-	return IPv6
+	return 128
 }
 
 // next returns the next IP address of xIP.
 func (ip xIP) next() xIP {
-	i := ipToInt(ip.IP)
-	i.Add(i, bigInt[1])
-
-	return xIP{intToIP(i)}
+	return xIP{ip.num.addOne(), ip.ver}
 }
 
 // nextN returns the next nth IP address of xIP.
 func (ip xIP) nextN(n *big.Int) xIP {
-	if n.Sign() == 0 {
-		return ip
-	}
+	u, _ := uint128FromBig(n)
 
-	i := ipToInt(ip.IP)
-	i.Add(i, n)
+	return ip.add(u)
+}
 
-	return xIP{intToIP(i)}
+// add returns the next nth IP address of xIP, where n is a uint128.
+func (ip xIP) add(n uint128) xIP {
+	return xIP{ip.num.add(n), ip.ver}
 }
 
 // prev returns the previous IP address of xIP.
 func (ip xIP) prev() xIP {
-	i := ipToInt(ip.IP)
-	i.Sub(i, bigInt[1])
-
-	return xIP{intToIP(i)}
+	return xIP{ip.num.subOne(), ip.ver}
 }
 
 // cmp compares xIP ip and ip2 with the same IP version and returns:
@@ -67,20 +152,16 @@ func (ip xIP) prev() xIP {
 //	 0: ip == ip2
 //	+1: ip >  ip2
 func (ip xIP) cmp(ip2 xIP) int {
-	nIP1 := normalizeIP(ip.IP)
-	nIP2 := normalizeIP(ip2.IP)
-
-	return ipToInt(nIP1).Cmp(ipToInt(nIP2))
+	return ip.num.cmp(ip2.num)
 }
 
-// ipToInt converts net.IP to a big number.
-func ipToInt(ip net.IP) *big.Int {
-	return new(big.Int).SetBytes(ip)
-}
+// String implements fmt.Stringer.
+func (ip xIP) String() string {
+	if ip.ver == Unknown {
+		return "<nil>"
+	}
 
-// ipToInt converts big number to a net.IP.
-func intToIP(i *big.Int) net.IP {
-	return net.IP(i.Bytes())
+	return ip.toAddr().String()
 }
 
 // normalizeIP normalizes net.IP by family:
@@ -9,7 +9,6 @@ type ipIterator struct {
 	ranges     []ipRange
 	rangeIndex int
 	current    xIP
-	freeSize   *big.Int
 }
 
 // IPIterator generates a new iterator for scanning IP addresses.
@@ -22,93 +21,293 @@ func (rr *IPRanges) IPIterator() *ipIterator {
 // Next returns the next IP address. If the ipIterator has been exhausted,
 // return nil.
 func (ii *ipIterator) Next() net.IP {
-	n := len(ii.ranges)
-	if n == 0 {
+	ip, ok := ii.next()
+	if !ok {
 		return nil
 	}
 
-	if ii.current.IP == nil {
-		ii.freeSize = new(big.Int)
-		ii.freeSize.Set(ii.ranges[0].size())
-		ii.freeSize.Sub(ii.freeSize, bigInt[1])
-		ii.current.IP = ii.ranges[0].start.IP
-		return ii.current.IP
+	return ip.toIP()
+}
+
+// next returns the next xIP, ok is false if the ipIterator has been
+// exhausted.
+func (ii *ipIterator) next() (ip xIP, ok bool) {
+	if ii.rangeIndex == len(ii.ranges) {
+		return xIP{}, false
+	}
+
+	if ii.current.version() == Unknown {
+		ii.current = ii.ranges[ii.rangeIndex].start
+		return ii.current, true
 	}
 
-	if !ii.current.Equal(ii.ranges[ii.rangeIndex].end.IP) {
-		ii.freeSize.Sub(ii.freeSize, bigInt[1])
+	if ii.current != ii.ranges[ii.rangeIndex].end {
 		ii.current = ii.current.next()
-		return ii.current.IP
+		return ii.current, true
 	}
 
 	ii.rangeIndex++
-	if ii.rangeIndex == n {
-		return nil
+	if ii.rangeIndex == len(ii.ranges) {
+		return xIP{}, false
 	}
-	ii.freeSize.Set(ii.ranges[ii.rangeIndex].size())
-	ii.freeSize.Sub(ii.freeSize, bigInt[1])
 	ii.current = ii.ranges[ii.rangeIndex].start
 
-	return ii.current.IP
+	return ii.current, true
 }
 
 // NextN returns the next nth IP address. If the ipIterator has been exhausted,
 // return nil. If n <= 0, it is equivalent to NextN(1).
 func (ii *ipIterator) NextN(n *big.Int) net.IP {
-	l := len(ii.ranges)
-	if l == 0 {
+	ip, ok := ii.nextN(n)
+	if !ok {
 		return nil
 	}
 
+	return ip.toIP()
+}
+
+// nextN returns the next nth xIP, ok is false if the ipIterator has been
+// exhausted.
+func (ii *ipIterator) nextN(n *big.Int) (ip xIP, ok bool) {
+	l := len(ii.ranges)
+	if ii.rangeIndex == l {
+		return xIP{}, false
+	}
+
 	if n.Sign() <= 0 {
-		n = big.NewInt(1)
+		n = bigInt[1]
 	}
 
-	if ii.current.IP == nil {
-		n = new(big.Int).Set(n)
-		for {
-			size := ii.ranges[ii.rangeIndex].size()
-			if n.Cmp(size) <= 0 {
-				ii.freeSize = new(big.Int)
-				ii.freeSize.Set(size)
-				ii.freeSize.Sub(ii.freeSize, n)
-				n.Sub(n, bigInt[1])
-				ii.current.IP = ii.ranges[ii.rangeIndex].start.nextN(n).IP
-				return ii.current.IP
-			}
-
-			n.Sub(n, size)
-			ii.rangeIndex++
-			if ii.rangeIndex == l {
-				return nil
-			}
+	// A step beyond uint128 is taken in chunks of the largest uint128. As
+	// no ipRange holds more than 2^128 IP addresses, every two chunks move
+	// the ipIterator past an ipRange, which bounds the recursion.
+	step, ok := uint128FromBig(n)
+	if !ok {
+		chunk := lowBits(128).big()
+		if _, ok := ii.nextN(chunk); !ok {
+			return xIP{}, false
 		}
+		return ii.nextN(new(big.Int).Sub(n, chunk))
 	}
 
-	if n.Cmp(ii.freeSize) <= 0 {
-		ii.freeSize.Sub(ii.freeSize, n)
-		ii.current.IP = ii.current.nextN(n).IP
-		return ii.current.IP
+	if ii.current.version() != Unknown {
+		free := ii.ranges[ii.rangeIndex].end.num.sub(ii.current.num)
+		if step.cmp(free) <= 0 {
+			ii.current = ii.current.add(step)
+			return ii.current, true
+		}
+		step = step.sub(free)
+		ii.rangeIndex++
 	}
 
-	ii.rangeIndex++
-	if ii.rangeIndex == l {
-		return nil
+	// From here on, step counts from the address before the start of the
+	// current range.
+	for ; ii.rangeIndex < l; ii.rangeIndex++ {
+		r := ii.ranges[ii.rangeIndex]
+		last := r.end.num.sub(r.start.num)
+		step = step.subOne()
+		if step.cmp(last) <= 0 {
+			ii.current = r.start.add(step)
+			return ii.current, true
+		}
+		step = step.sub(last)
 	}
-	n = new(big.Int).Set(n)
-	n.Sub(n, ii.freeSize)
-	ii.freeSize.Set(ii.ranges[ii.rangeIndex].size())
-	ii.freeSize.Sub(ii.freeSize, n)
-	n.Sub(n, bigInt[1])
-	ii.current.IP = ii.ranges[ii.rangeIndex].start.nextN(n).IP
 
-	return ii.current.IP
+	return xIP{}, false
 }
 
 // Reset resets IP iterator.
 func (ii *ipIterator) Reset() {
 	ii.rangeIndex = 0
-	ii.current.IP = nil
+	ii.current = xIP{}
+}
+
+// Prev returns the previous IP address, i.e. the one returned by the last
+// call to Next, and moves the ipIterator backward, so that Next and Prev
+// can be called alternately to scan in both directions. If the ipIterator
+// is at the start, return nil. Prev after the ipIterator has been
+// exhausted returns the last IP address.
+func (ii *ipIterator) Prev() net.IP {
+	ip, ok := ii.prev()
+	if !ok {
+		return nil
+	}
+
+	return ip.toIP()
+}
+
+// PrevN returns the previous nth IP address, and moves the ipIterator
+// backward accordingly. If there are less than n IP addresses before the
+// ipIterator, it moves to the start and returns nil. If n <= 0, it is
+// equivalent to PrevN(1).
+func (ii *ipIterator) PrevN(n *big.Int) net.IP {
+	ip, ok := ii.prevN(n)
+	if !ok {
+		return nil
+	}
+
+	return ip.toIP()
+}
+
+// Seek moves the ipIterator to net.IP ip, so that the next call to Next
+// returns ip, and the next call to Prev returns the IP address before ip.
+// If ip pertains to multiple IP ranges, the first one is taken. It reports
+// false and leaves the ipIterator untouched if ip is not scanned by the
+// ipIterator at all.
+func (ii *ipIterator) Seek(ip net.IP) bool {
+	w := ipToXIP(ip)
+	for i, r := range ii.ranges {
+		if r.start.version() == w.version() && r.contains(w) {
+			ii.seek(i, w)
+			return true
+		}
+	}
+
+	return false
+}
+
+// SeekIndex moves the ipIterator to the IP address at index n, counting
+// from 0, so that the next call to Next returns it. If n is equal to the
+// number of IP addresses, the ipIterator is moved to the end, where Next
+// returns nil and Prev returns the last IP address. It reports false and
+// leaves the ipIterator untouched if n is negative or larger than that.
+func (ii *ipIterator) SeekIndex(n *big.Int) bool {
+	if n.Sign() < 0 {
+		return false
+	}
+
+	left := new(big.Int).Set(n)
+	for i, r := range ii.ranges {
+		size := r.size()
+		if left.Cmp(size) < 0 {
+			offset, _ := uint128FromBig(left)
+			ii.seek(i, r.start.add(offset))
+			return true
+		}
+		left.Sub(left, size)
+	}
+
+	if left.Sign() != 0 {
+		return false
+	}
+	ii.rangeIndex = len(ii.ranges)
+	ii.current = xIP{}
+
+	return true
+}
+
+// seek moves the ipIterator to xIP w of the ipRange at index i.
+func (ii *ipIterator) seek(i int, w xIP) {
+	ii.rangeIndex = i
+	ii.current = xIP{}
+	if w != ii.ranges[i].start {
+		ii.current = w.prev()
+	}
+}
+
+// prev returns the previous xIP, ok is false if the ipIterator is at the
+// start.
+//
+// current is the xIP before the position of the ipIterator within the
+// ipRange at rangeIndex, or unknown if the position is at the start of
+// that ipRange.
+func (ii *ipIterator) prev() (ip xIP, ok bool) {
+	if !ii.backward() {
+		return xIP{}, false
+	}
+
+	ip = ii.current
+	if ip == ii.ranges[ii.rangeIndex].start {
+		ii.current = xIP{}
+	} else {
+		ii.current = ip.prev()
+	}
+
+	return ip, true
+}
+
+// prevN returns the previous nth xIP, ok is false if there are less than n
+// xIP before the ipIterator.
+func (ii *ipIterator) prevN(n *big.Int) (ip xIP, ok bool) {
+	if n.Sign() <= 0 {
+		n = bigInt[1]
+	}
+
+	// skip is the number of xIP to skip before the one to return.
+	skip := new(big.Int).Sub(n, bigInt[1])
+	for ii.backward() {
+		r := ii.ranges[ii.rangeIndex]
+		before := ii.current.num.sub(r.start.num).big()
+		if skip.Cmp(before) <= 0 {
+			step, _ := uint128FromBig(skip)
+			ii.current = xIP{ii.current.num.sub(step), ii.current.ver}
+			return ii.prev()
+		}
+
+		skip.Sub(skip, before)
+		skip.Sub(skip, bigInt[1])
+		ii.current = xIP{}
+	}
+	ii.Reset()
+
+	return xIP{}, false
+}
+
+// backward makes current the xIP right before the position of the
+// ipIterator, stepping back to the previous ipRange if needed. It reports
+// false if there is no such xIP.
+func (ii *ipIterator) backward() bool {
+	if ii.rangeIndex == len(ii.ranges) || ii.current.version() == Unknown {
+		if ii.rangeIndex == 0 {
+			return false
+		}
+		ii.rangeIndex--
+		ii.current = ii.ranges[ii.rangeIndex].end
+	}
+
+	return true
+}
+
+type reverseIPIterator struct {
+	iter *ipIterator
+}
+
+// ReverseIPIterator generates a new iterator for scanning IP addresses
+// backward, from the last IP address of IPRanges rr, which is the highest
+// one if rr is merged, to the first one.
+func (rr *IPRanges) ReverseIPIterator() *reverseIPIterator {
+	return newReverseIPIterator(rr.ranges)
+}
+
+// newReverseIPIterator generates a new iterator for scanning IP addresses
+// of ranges backward.
+func newReverseIPIterator(ranges []ipRange) *reverseIPIterator {
+	ri := &reverseIPIterator{
+		iter: &ipIterator{
+			ranges: ranges,
+		},
+	}
+	ri.Reset()
+
+	return ri
+}
+
+// Next returns the next IP address backward. If the reverseIPIterator has
+// been exhausted, return nil.
+func (ri *reverseIPIterator) Next() net.IP {
+	return ri.iter.Prev()
+}
+
+// NextN returns the next nth IP address backward. If the reverseIPIterator
+// has been exhausted, return nil. If n <= 0, it is equivalent to NextN(1).
+func (ri *reverseIPIterator) NextN(n *big.Int) net.IP {
+	return ri.iter.PrevN(n)
+}
+
+// Reset resets reverse IP iterator.
+func (ri *reverseIPIterator) Reset() {
+	ri.iter.rangeIndex = len(ri.iter.ranges)
+	ri.iter.current = xIP{}
 }
 
 type blockIterator struct {
@@ -193,23 +392,60 @@ func (bi *blockIterator) Reset() {
 type cidrIterator struct {
 	ranges     []ipRange
 	rangeIndex int
-
-	ipBitLen int
-	lastInt  *big.Int
-	current  *big.Int
+	current    xIP
+	// minOnes is the shortest prefix length of the CIDRs, the larger CIDRs
+	// are split into the ones of this prefix length.
+	minOnes int
 }
 
 // CIDRIterator generates a new iterator for scanning CIDR.
 func (rr *IPRanges) CIDRIterator() *cidrIterator {
+	return newCIDRIterator(rr.ranges)
+}
+
+// BoundedCIDRIterator generates a new iterator for scanning CIDR, whose
+// prefix lengths are between minOnes and maxOnes. A CIDR shorter than
+// minOnes is split into the ones of prefix length minOnes, such as a /8
+// into 256 /16, while the IP addresses not covered by any CIDR of prefix
+// length maxOnes are widened to cover, such as 10.0.0.1-10.0.2.3 into
+// 10.0.0.0/23 and 10.0.2.0/24 when maxOnes is 24. So the CIDRs cover but
+// may exceed IPRanges rr, and BoundedCIDRIterator(24, 24) scans every /24
+// that covers rr.
+//
+// minOnes and maxOnes are clamped to the bit length of the IP addresses,
+// and maxOnes is taken as minOnes if it is less.
+func (rr *IPRanges) BoundedCIDRIterator(minOnes, maxOnes int) *cidrIterator {
+	bits := 32
+	if rr.version == IPv6 {
+		bits = 128
+	}
+	minOnes = minN(maxN(minOnes, 0), bits)
+	maxOnes = minN(maxN(maxOnes, minOnes), bits)
+
+	host := lowBits(bits - maxOnes)
+	ranges := make([]ipRange, 0, len(rr.ranges))
+	for _, r := range rr.ranges {
+		ranges = append(ranges, ipRange{
+			start: xIP{r.start.num.and(host.not()), r.start.version()},
+			end:   xIP{r.end.num.or(host), r.end.version()},
+		})
+	}
+
+	iter := newCIDRIterator(mergeRanges(ranges))
+	iter.minOnes = minOnes
+
+	return iter
+}
+
+// newCIDRIterator generates a new cidrIterator over ranges, which may be of
+// different IP versions.
+func newCIDRIterator(ranges []ipRange) *cidrIterator {
 	iter := &cidrIterator{
-		ranges: rr.ranges,
+		ranges: ranges,
 	}
 
 	if len(iter.ranges) != 0 {
-		r := iter.ranges[0]
-		iter.ipBitLen = len(r.start.IP) * 8
-		iter.lastInt = ipToInt(r.end.IP)
-		iter.current = ipToInt(r.start.IP)
+		iter.current = iter.ranges[0].start
 	}
 
 	return iter
@@ -218,58 +454,45 @@ func (rr *IPRanges) CIDRIterator() *cidrIterator {
 // Next returns the next CIDR. If the cidrIterator has been exhausted,
 // return nil.
 func (ci *cidrIterator) Next() *net.IPNet {
-	n := len(ci.ranges)
-	if n == 0 {
+	ip, ones, ok := ci.next()
+	if !ok {
 		return nil
 	}
+	bits := ip.bitLen()
 
-	if ci.current.Cmp(ci.lastInt) <= 0 {
-		return ci.next()
-	}
-
-	ci.rangeIndex++
-	if ci.rangeIndex == n {
-		return nil
+	return &net.IPNet{
+		IP:   ip.toIP(),
+		Mask: net.CIDRMask(ones, bits),
 	}
-	ci.lastInt = ipToInt(ci.ranges[ci.rangeIndex].end.IP)
-	ci.current = ipToInt(ci.ranges[ci.rangeIndex].start.IP)
-
-	return ci.next()
 }
 
-func (ci *cidrIterator) next() *net.IPNet {
-	delta := new(big.Int).Sub(ci.lastInt, ci.current)
-	delta.Add(delta, bigInt[1])
-
-	curIP := intToIP(ci.current)
-	nbits := minN(righthandZeroBits(curIP), delta.BitLen()-1)
+// next returns the first xIP and the prefix length of the next CIDR, ok is
+// false if the cidrIterator has been exhausted.
+func (ci *cidrIterator) next() (ip xIP, ones int, ok bool) {
+	if ci.rangeIndex == len(ci.ranges) {
+		return xIP{}, 0, false
+	}
 
-	incr := new(big.Int).Lsh(bigInt[1], uint(nbits))
-	ci.current.Add(ci.current, incr)
+	ip = ci.current
+	r := ci.ranges[ci.rangeIndex]
+	bits := ip.bitLen()
 
-	return &net.IPNet{
-		IP:   curIP,
-		Mask: net.CIDRMask(ci.ipBitLen-nbits, ci.ipBitLen),
+	// The CIDR is limited by both the alignment of the current IP address
+	// and the number of IP addresses left in the current range.
+	nbits := minN(ip.num.trailingZeros(), bits-ci.minOnes)
+	if left := r.end.num.sub(ip.num); left != maxUint128 {
+		nbits = minN(nbits, left.addOne().bitLen()-1)
 	}
-}
-
-// righthandZeroBits counts the number of zero bits on the right hand
-// side of bb.
-func righthandZeroBits(bb []byte) int {
-	n := 0
-	for i := len(bb) - 1; i >= 0; i-- {
-		b := bb[i]
-		if b == 0 {
-			n += 8
-			continue
-		}
 
-		for b&1 == 0 {
-			n++
-			b >>= 1
+	last := ip.add(lowBits(nbits))
+	if last == r.end {
+		ci.rangeIndex++
+		if ci.rangeIndex < len(ci.ranges) {
+			ci.current = ci.ranges[ci.rangeIndex].start
 		}
-		break
+	} else {
+		ci.current = last.next()
 	}
 
-	return n
+	return ip, bits - nbits, true
 }
@@ -1,6 +1,7 @@
 package iprange
 
 import (
+	"fmt"
 	"math/big"
 	"net"
 	"testing"
@@ -19,12 +20,12 @@ var ipRangesIPIteratorNextTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 10).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 10).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 2).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 2).To4()),
 				},
 			},
 		},
@@ -40,12 +41,12 @@ var ipRangesIPIteratorNextTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::a")},
-					end:   xIP{net.ParseIP("fd00::a")},
+					start: ipToXIP(net.ParseIP("fd00::a")),
+					end:   ipToXIP(net.ParseIP("fd00::a")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::1")},
-					end:   xIP{net.ParseIP("fd00::2")},
+					start: ipToXIP(net.ParseIP("fd00::1")),
+					end:   ipToXIP(net.ParseIP("fd00::2")),
 				},
 			},
 		},
@@ -98,12 +99,12 @@ var ipRangesIPIteratorNextNTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 10).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 10).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 2).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 2).To4()),
 				},
 			},
 		},
@@ -120,16 +121,16 @@ var ipRangesIPIteratorNextNTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::a")},
-					end:   xIP{net.ParseIP("fd00::a")},
+					start: ipToXIP(net.ParseIP("fd00::a")),
+					end:   ipToXIP(net.ParseIP("fd00::a")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::2")},
-					end:   xIP{net.ParseIP("fd00::3")},
+					start: ipToXIP(net.ParseIP("fd00::2")),
+					end:   ipToXIP(net.ParseIP("fd00::3")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::1")},
-					end:   xIP{net.ParseIP("fd00::2")},
+					start: ipToXIP(net.ParseIP("fd00::1")),
+					end:   ipToXIP(net.ParseIP("fd00::2")),
 				},
 			},
 		},
@@ -139,14 +140,39 @@ var ipRangesIPIteratorNextNTests = []struct {
 			net.ParseIP("fd00::1"),
 		},
 	},
+	{
+		name: "across ranges",
+		ranges: &IPRanges{
+			version: IPv4,
+			ranges: []ipRange{
+				{
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 2).To4()),
+				},
+				{
+					start: ipToXIP(net.IPv4(172, 18, 0, 5).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 5).To4()),
+				},
+				{
+					start: ipToXIP(net.IPv4(172, 18, 0, 9).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 15).To4()),
+				},
+			},
+		},
+		n: big.NewInt(4),
+		want: []net.IP{
+			net.IPv4(172, 18, 0, 9).To4(),
+			net.IPv4(172, 18, 0, 13).To4(),
+		},
+	},
 	{
 		name: "out of ranges",
 		ranges: &IPRanges{
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 10).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 10).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
 				},
 			},
 		},
@@ -159,6 +185,18 @@ var ipRangesIPIteratorNextNTests = []struct {
 		n:      big.NewInt(1),
 		want:   nil,
 	},
+	{
+		name:   "IPv6 whole space",
+		ranges: mustParse("::/0"),
+		n:      new(big.Int).Lsh(big.NewInt(1), 128),
+		want:   []net.IP{net.ParseIP("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff")},
+	},
+	{
+		name:   "beyond uint128",
+		ranges: mustParse("::/0", "::/0"),
+		n:      new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1)),
+		want:   []net.IP{net.ParseIP("::")},
+	},
 }
 
 func TestIPRangesIPIteratorNextN(t *testing.T) {
@@ -185,6 +223,206 @@ func TestIPRangesIPIteratorNextN(t *testing.T) {
 	}
 }
 
+func TestIPRangesReverseIPIterator(t *testing.T) {
+	t.Parallel()
+	for _, test := range ipRangesIPIteratorNextTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			iter := test.ranges.ReverseIPIterator()
+
+			var ips []net.IP
+			for {
+				ip := iter.Next()
+				if ip == nil {
+					break
+				}
+				ips = append([]net.IP{ip}, ips...)
+			}
+
+			if !cmp.Equal(ips, test.want) {
+				t.Fatalf("IPRanges(%v).ReverseIPIterator().Next() = %v, want reversed %v", test.ranges, ips, test.want)
+			}
+		})
+	}
+}
+
+var ipRangesReverseIPIteratorNextNTests = []struct {
+	name   string
+	ranges []string
+	n      *big.Int
+	want   []string
+}{
+	{"IPv4", []string{"172.18.0.1-3", "172.18.0.10-12"}, big.NewInt(2), []string{"172.18.0.11", "172.18.0.3", "172.18.0.1"}},
+	{"IPv6", []string{"fd00::1-3", "fd00::a"}, big.NewInt(3), []string{"fd00::2"}},
+	{"across ranges", []string{"172.18.0.1-3", "172.18.0.5", "172.18.0.10-12"}, big.NewInt(5), []string{"172.18.0.3"}},
+	{"n <= 0", []string{"172.18.0.1-2"}, big.NewInt(0), []string{"172.18.0.2", "172.18.0.1"}},
+	{"huge", []string{"::/0"}, new(big.Int).Lsh(big.NewInt(1), 127), []string{"8000::", "::"}},
+	{"zero", []string{}, big.NewInt(1), nil},
+}
+
+func TestIPRangesReverseIPIteratorNextN(t *testing.T) {
+	t.Parallel()
+	for _, test := range ipRangesReverseIPIteratorNextNTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ranges, err := Parse(test.ranges...)
+			if err != nil {
+				t.Fatalf("Parse(%q) err %q", test.ranges, err)
+			}
+			iter := ranges.ReverseIPIterator()
+
+			var ips []string
+			for {
+				ip := iter.NextN(test.n)
+				if ip == nil {
+					break
+				}
+				ips = append(ips, ip.String())
+			}
+
+			if !cmp.Equal(ips, test.want) {
+				t.Fatalf("IPRanges(%v).ReverseIPIterator().NextN(%v) = %v, want %v", ranges, test.n, ips, test.want)
+			}
+		})
+	}
+}
+
+func TestIPRangesIPIteratorPrev(t *testing.T) {
+	t.Parallel()
+	ranges, err := Parse("172.18.0.1-2", "172.18.0.10")
+	if err != nil {
+		t.Fatalf("Parse() err %q", err)
+	}
+
+	// A step is either "next" or "prev", along with the IP address
+	// returned.
+	steps := []struct {
+		op   string
+		want string
+	}{
+		{"prev", "<nil>"},
+		{"next", "172.18.0.1"},
+		{"next", "172.18.0.2"},
+		{"prev", "172.18.0.2"},
+		{"prev", "172.18.0.1"},
+		{"prev", "<nil>"},
+		{"next", "172.18.0.1"},
+		{"next", "172.18.0.2"},
+		{"next", "172.18.0.10"},
+		{"next", "<nil>"},
+		{"prev", "172.18.0.10"},
+		{"prev", "172.18.0.2"},
+		{"next", "172.18.0.2"},
+		{"next", "172.18.0.10"},
+	}
+
+	iter := ranges.IPIterator()
+	for i, step := range steps {
+		var ip net.IP
+		if step.op == "next" {
+			ip = iter.Next()
+		} else {
+			ip = iter.Prev()
+		}
+		if ip.String() != step.want {
+			t.Fatalf("IPRanges(%v).IPIterator() step %d %s() = %v, want %v", ranges, i, step.op, ip, step.want)
+		}
+	}
+
+	iter.Reset()
+	iter.NextN(big.NewInt(3))
+	if ip := iter.PrevN(big.NewInt(2)); ip.String() != "172.18.0.2" {
+		t.Fatalf("IPRanges(%v).IPIterator().PrevN(2) = %v, want 172.18.0.2", ranges, ip)
+	}
+	if ip := iter.PrevN(big.NewInt(2)); ip != nil {
+		t.Fatalf("IPRanges(%v).IPIterator().PrevN(2) = %v, want nil", ranges, ip)
+	}
+	if ip := iter.Next(); ip.String() != "172.18.0.1" {
+		t.Fatalf("IPRanges(%v).IPIterator().Next() = %v after PrevN exhausted, want 172.18.0.1", ranges, ip)
+	}
+}
+
+var ipRangesIPIteratorSeekTests = []struct {
+	name     string
+	ranges   []string
+	seek     net.IP
+	seekN    *big.Int
+	ok       bool
+	wantNext string
+	wantPrev string
+}{
+	{"IPv4", []string{"172.18.0.1-3", "172.18.0.10-12"}, net.ParseIP("172.18.0.11"), big.NewInt(4), true, "172.18.0.11", "172.18.0.10"},
+	{"range start", []string{"172.18.0.1-3", "172.18.0.10-12"}, net.ParseIP("172.18.0.10"), big.NewInt(3), true, "172.18.0.10", "172.18.0.3"},
+	{"first", []string{"fd00::1-3"}, net.ParseIP("fd00::1"), big.NewInt(0), true, "fd00::1", "<nil>"},
+	{"not contained", []string{"fd00::1-3"}, net.ParseIP("fd00::4"), big.NewInt(4), false, "fd00::1", "<nil>"},
+	{"end", []string{"fd00::1-3"}, nil, big.NewInt(3), true, "<nil>", "fd00::3"},
+	{"negative", []string{"fd00::1-3"}, nil, big.NewInt(-1), false, "fd00::1", "<nil>"},
+	{"other version", []string{"::/120"}, net.ParseIP("0.0.0.5"), big.NewInt(-1), false, "::", "<nil>"},
+}
+
+func TestIPRangesIPIteratorSeek(t *testing.T) {
+	t.Parallel()
+	for _, test := range ipRangesIPIteratorSeekTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ranges, err := Parse(test.ranges...)
+			if err != nil {
+				t.Fatalf("Parse(%q) err %q", test.ranges, err)
+			}
+
+			// After moving to an IP address, Next returns it, and then
+			// Prev returns it again, and the one before it.
+			check := func(iter *ipIterator, op string, ok bool) {
+				if ok != test.ok {
+					t.Fatalf("IPRanges(%v).IPIterator().%s = %v, want %v", ranges, op, ok, test.ok)
+				}
+				next := iter.Next()
+				if next.String() != test.wantNext {
+					t.Fatalf("IPRanges(%v).IPIterator().Next() = %v after %s, want %v", ranges, next, op, test.wantNext)
+				}
+				if next != nil {
+					if prev := iter.Prev(); !prev.Equal(next) {
+						t.Fatalf("IPRanges(%v).IPIterator().Prev() = %v after Next, want %v", ranges, prev, next)
+					}
+				}
+				if prev := iter.Prev(); prev.String() != test.wantPrev {
+					t.Fatalf("IPRanges(%v).IPIterator().Prev() = %v after %s, want %v", ranges, prev, op, test.wantPrev)
+				}
+			}
+
+			if test.seek != nil {
+				iter := ranges.IPIterator()
+				check(iter, fmt.Sprintf("Seek(%v)", test.seek), iter.Seek(test.seek))
+			}
+			iter := ranges.IPIterator()
+			check(iter, fmt.Sprintf("SeekIndex(%v)", test.seekN), iter.SeekIndex(test.seekN))
+		})
+	}
+}
+
+func TestDualStackRangesIPIteratorSeek(t *testing.T) {
+	t.Parallel()
+	ranges, err := ParseDualStack("0.0.0.0/24", "fd00::1")
+	if err != nil {
+		t.Fatalf("ParseDualStack() err %q", err)
+	}
+
+	// ::5 is not in 0.0.0.0/24, although their numbers are.
+	iter := ranges.IPIterator()
+	if iter.Seek(net.ParseIP("::5")) {
+		t.Fatalf("DualStackRanges(%v).IPIterator().Seek(::5) = true, want false", ranges)
+	}
+	if !iter.Seek(net.ParseIP("0.0.0.5")) {
+		t.Fatalf("DualStackRanges(%v).IPIterator().Seek(0.0.0.5) = false, want true", ranges)
+	}
+	if ip := iter.Next(); ip.String() != "0.0.0.5" {
+		t.Fatalf("DualStackRanges(%v).IPIterator().Next() = %v after Seek(0.0.0.5), want 0.0.0.5", ranges, ip)
+	}
+}
+
 var ipRangesBlockIteratorNextTests = []struct {
 	name      string
 	ranges    *IPRanges
@@ -197,12 +435,12 @@ var ipRangesBlockIteratorNextTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 10).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 10).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 2).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 2).To4()),
 				},
 			},
 		},
@@ -212,8 +450,8 @@ var ipRangesBlockIteratorNextTests = []struct {
 				version: IPv4,
 				ranges: []ipRange{
 					{
-						start: xIP{net.IPv4(172, 18, 0, 10).To4()},
-						end:   xIP{net.IPv4(172, 18, 0, 10).To4()},
+						start: ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
+						end:   ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
 					},
 				},
 			},
@@ -221,8 +459,8 @@ var ipRangesBlockIteratorNextTests = []struct {
 				version: IPv4,
 				ranges: []ipRange{
 					{
-						start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-						end:   xIP{net.IPv4(172, 18, 0, 1).To4()},
+						start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+						end:   ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
 					},
 				},
 			},
@@ -230,8 +468,8 @@ var ipRangesBlockIteratorNextTests = []struct {
 				version: IPv4,
 				ranges: []ipRange{
 					{
-						start: xIP{net.IPv4(172, 18, 0, 2).To4()},
-						end:   xIP{net.IPv4(172, 18, 0, 2).To4()},
+						start: ipToXIP(net.IPv4(172, 18, 0, 2).To4()),
+						end:   ipToXIP(net.IPv4(172, 18, 0, 2).To4()),
 					},
 				},
 			},
@@ -243,12 +481,12 @@ var ipRangesBlockIteratorNextTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::a")},
-					end:   xIP{net.ParseIP("fd00::a")},
+					start: ipToXIP(net.ParseIP("fd00::a")),
+					end:   ipToXIP(net.ParseIP("fd00::a")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::6")},
-					end:   xIP{net.ParseIP("fd00::9")},
+					start: ipToXIP(net.ParseIP("fd00::6")),
+					end:   ipToXIP(net.ParseIP("fd00::9")),
 				},
 			},
 		},
@@ -258,12 +496,12 @@ var ipRangesBlockIteratorNextTests = []struct {
 				version: IPv6,
 				ranges: []ipRange{
 					{
-						start: xIP{net.ParseIP("fd00::a")},
-						end:   xIP{net.ParseIP("fd00::a")},
+						start: ipToXIP(net.ParseIP("fd00::a")),
+						end:   ipToXIP(net.ParseIP("fd00::a")),
 					},
 					{
-						start: xIP{net.ParseIP("fd00::6")},
-						end:   xIP{net.ParseIP("fd00::6")},
+						start: ipToXIP(net.ParseIP("fd00::6")),
+						end:   ipToXIP(net.ParseIP("fd00::6")),
 					},
 				},
 			},
@@ -271,8 +509,8 @@ var ipRangesBlockIteratorNextTests = []struct {
 				version: IPv6,
 				ranges: []ipRange{
 					{
-						start: xIP{net.ParseIP("fd00::7")},
-						end:   xIP{net.ParseIP("fd00::8")},
+						start: ipToXIP(net.ParseIP("fd00::7")),
+						end:   ipToXIP(net.ParseIP("fd00::8")),
 					},
 				},
 			},
@@ -280,8 +518,8 @@ var ipRangesBlockIteratorNextTests = []struct {
 				version: IPv6,
 				ranges: []ipRange{
 					{
-						start: xIP{net.ParseIP("fd00::9")},
-						end:   xIP{net.ParseIP("fd00::9")},
+						start: ipToXIP(net.ParseIP("fd00::9")),
+						end:   ipToXIP(net.ParseIP("fd00::9")),
 					},
 				},
 			},
@@ -332,12 +570,12 @@ var ipRangesBlockIteratorNextNTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 10).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 10).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 2).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 2).To4()),
 				},
 			},
 		},
@@ -348,8 +586,8 @@ var ipRangesBlockIteratorNextNTests = []struct {
 				version: IPv4,
 				ranges: []ipRange{
 					{
-						start: xIP{net.IPv4(172, 18, 0, 10).To4()},
-						end:   xIP{net.IPv4(172, 18, 0, 10).To4()},
+						start: ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
+						end:   ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
 					},
 				},
 			},
@@ -357,8 +595,8 @@ var ipRangesBlockIteratorNextNTests = []struct {
 				version: IPv4,
 				ranges: []ipRange{
 					{
-						start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-						end:   xIP{net.IPv4(172, 18, 0, 1).To4()},
+						start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+						end:   ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
 					},
 				},
 			},
@@ -366,8 +604,8 @@ var ipRangesBlockIteratorNextNTests = []struct {
 				version: IPv4,
 				ranges: []ipRange{
 					{
-						start: xIP{net.IPv4(172, 18, 0, 2).To4()},
-						end:   xIP{net.IPv4(172, 18, 0, 2).To4()},
+						start: ipToXIP(net.IPv4(172, 18, 0, 2).To4()),
+						end:   ipToXIP(net.IPv4(172, 18, 0, 2).To4()),
 					},
 				},
 			},
@@ -379,12 +617,12 @@ var ipRangesBlockIteratorNextNTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::a")},
-					end:   xIP{net.ParseIP("fd00::a")},
+					start: ipToXIP(net.ParseIP("fd00::a")),
+					end:   ipToXIP(net.ParseIP("fd00::a")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::6")},
-					end:   xIP{net.ParseIP("fd00::b")},
+					start: ipToXIP(net.ParseIP("fd00::6")),
+					end:   ipToXIP(net.ParseIP("fd00::b")),
 				},
 			},
 		},
@@ -395,8 +633,8 @@ var ipRangesBlockIteratorNextNTests = []struct {
 				version: IPv6,
 				ranges: []ipRange{
 					{
-						start: xIP{net.ParseIP("fd00::7")},
-						end:   xIP{net.ParseIP("fd00::8")},
+						start: ipToXIP(net.ParseIP("fd00::7")),
+						end:   ipToXIP(net.ParseIP("fd00::8")),
 					},
 				},
 			},
@@ -404,8 +642,8 @@ var ipRangesBlockIteratorNextNTests = []struct {
 				version: IPv6,
 				ranges: []ipRange{
 					{
-						start: xIP{net.ParseIP("fd00::b")},
-						end:   xIP{net.ParseIP("fd00::b")},
+						start: ipToXIP(net.ParseIP("fd00::b")),
+						end:   ipToXIP(net.ParseIP("fd00::b")),
 					},
 				},
 			},
@@ -455,12 +693,12 @@ var ipRangesCIDRIteratorTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 1, 0).To4()},
-					end:   xIP{net.IPv4(172, 18, 1, 255).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 1, 0).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 1, 255).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 3).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 3).To4()),
 				},
 			},
 		},
@@ -485,12 +723,12 @@ var ipRangesCIDRIteratorTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::0")},
-					end:   xIP{net.ParseIP("fd00::ffff:ffff:ffff:ffff")},
+					start: ipToXIP(net.ParseIP("fd00::0")),
+					end:   ipToXIP(net.ParseIP("fd00::ffff:ffff:ffff:ffff")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::1")},
-					end:   xIP{net.ParseIP("fd00::3")},
+					start: ipToXIP(net.ParseIP("fd00::1")),
+					end:   ipToXIP(net.ParseIP("fd00::3")),
 				},
 			},
 		},
@@ -509,6 +747,46 @@ var ipRangesCIDRIteratorTests = []struct {
 			},
 		},
 	},
+	{
+		name: "full IPv6",
+		ranges: &IPRanges{
+			version: IPv6,
+			ranges: []ipRange{
+				{
+					start: ipToXIP(net.ParseIP("::")),
+					end:   ipToXIP(net.ParseIP("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff")),
+				},
+			},
+		},
+		want: []*net.IPNet{
+			{
+				IP:   net.ParseIP("::"),
+				Mask: net.CIDRMask(0, 128),
+			},
+		},
+	},
+	{
+		name: "IPv4 last",
+		ranges: &IPRanges{
+			version: IPv4,
+			ranges: []ipRange{
+				{
+					start: ipToXIP(net.IPv4(255, 255, 255, 253).To4()),
+					end:   ipToXIP(net.IPv4(255, 255, 255, 255).To4()),
+				},
+			},
+		},
+		want: []*net.IPNet{
+			{
+				IP:   net.IPv4(255, 255, 255, 253).To4(),
+				Mask: net.CIDRMask(32, 32),
+			},
+			{
+				IP:   net.IPv4(255, 255, 255, 254).To4(),
+				Mask: net.CIDRMask(31, 32),
+			},
+		},
+	},
 	{
 		name:   "zero",
 		ranges: &IPRanges{},
@@ -539,3 +817,98 @@ func TestIPRangesCIDRIterator(t *testing.T) {
 		})
 	}
 }
+
+func TestIPRangesBoundedCIDRIterator(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		ranges  *IPRanges
+		minOnes int
+		maxOnes int
+		want    []string
+	}{
+		{
+			name:    "unbounded",
+			ranges:  mustParse("172.18.0.1-172.18.0.6"),
+			minOnes: 0,
+			maxOnes: 32,
+			want:    []string{"172.18.0.1/32", "172.18.0.2/31", "172.18.0.4/31", "172.18.0.6/32"},
+		},
+		{
+			name:    "split",
+			ranges:  mustParse("10.0.0.0/14", "10.4.0.0/16"),
+			minOnes: 16,
+			maxOnes: 32,
+			want:    []string{"10.0.0.0/16", "10.1.0.0/16", "10.2.0.0/16", "10.3.0.0/16", "10.4.0.0/16"},
+		},
+		{
+			name:    "widen",
+			ranges:  mustParse("10.0.0.1-10.0.2.3"),
+			minOnes: 0,
+			maxOnes: 24,
+			want:    []string{"10.0.0.0/23", "10.0.2.0/24"},
+		},
+		{
+			name:    "every /24",
+			ranges:  mustParse("10.0.0.1-10.0.2.3", "10.0.2.200", "10.0.9.0/25"),
+			minOnes: 24,
+			maxOnes: 24,
+			want:    []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24", "10.0.9.0/24"},
+		},
+		{
+			name:    "IPv6",
+			ranges:  mustParse("fd00::1-fd00:0:0:1::1"),
+			minOnes: 64,
+			maxOnes: 64,
+			want:    []string{"fd00::/64", "fd00:0:0:1::/64"},
+		},
+		{
+			name:    "clamped",
+			ranges:  mustParse("172.18.0.1-172.18.0.2"),
+			minOnes: 40,
+			maxOnes: 8,
+			want:    []string{"172.18.0.1/32", "172.18.0.2/32"},
+		},
+		{
+			name:    "zero",
+			ranges:  mustParse(),
+			minOnes: 24,
+			maxOnes: 24,
+			want:    nil,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []string
+			iter := test.ranges.BoundedCIDRIterator(test.minOnes, test.maxOnes)
+			for ipNet := iter.Next(); ipNet != nil; ipNet = iter.Next() {
+				got = append(got, ipNet.String())
+			}
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("IPRanges(%v).BoundedCIDRIterator(%d, %d) = %v, want %v", test.ranges, test.minOnes, test.maxOnes, got, test.want)
+			}
+		})
+	}
+}
+
+func BenchmarkIPRangesIPIteratorNext(b *testing.B) {
+	for _, r := range []string{"172.16.0.0/12", "fd00::/104"} {
+		ranges, err := Parse(r)
+		if err != nil {
+			b.Fatalf("Parse(%q) err %q", r, err)
+		}
+		b.Run(ranges.Version().String(), func(b *testing.B) {
+			b.ReportAllocs()
+			iter := ranges.IPIterator()
+			for i := 0; i < b.N; i++ {
+				if iter.Next() == nil {
+					iter.Reset()
+				}
+			}
+		})
+	}
+}
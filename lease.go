@@ -0,0 +1,263 @@
+package iprange
+
+import (
+	"container/heap"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the current time, which decides when leases expire. Tests
+// can inject a Clock to advance time deterministically.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the Clock of the system.
+type systemClock struct{}
+
+// Now implements Clock.
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Lease is an IP address allocated to an owner until it expires.
+type Lease struct {
+	IP     net.IP
+	Owner  string
+	Expiry time.Time
+}
+
+// LeaseAllocator allocates IP addresses of an Allocator as leases, which
+// expire after a TTL unless they are renewed. Expired leases are reclaimed
+// automatically: their IP addresses are released back to the Allocator
+// before each operation of the LeaseAllocator.
+//
+// IP addresses allocated from the Allocator directly are not leases, and
+// never expire, while the IP addresses of leases must not be released from
+// the Allocator directly. A LeaseAllocator is safe for concurrent use by
+// multiple goroutines.
+type LeaseAllocator struct {
+	mu     sync.Mutex
+	alloc  *Allocator
+	clock  Clock
+	leases map[xIP]*lease
+	// expiries orders the leases by their expiry time.
+	expiries leaseHeap
+}
+
+// NewLeaseAllocator returns a LeaseAllocator which allocates IP addresses
+// of Allocator a, and tells the time with Clock clock. If clock is nil,
+// the system clock is used.
+func NewLeaseAllocator(a *Allocator, clock Clock) *LeaseAllocator {
+	if clock == nil {
+		clock = systemClock{}
+	}
+
+	return &LeaseAllocator{
+		alloc:  a,
+		clock:  clock,
+		leases: make(map[xIP]*lease),
+	}
+}
+
+// AllocateWithTTL allocates an IP address for owner, whose lease expires
+// after ttl. The error errPoolExhausted will be returned when there is no
+// free IP address even after the expired leases are reclaimed.
+func (l *LeaseAllocator) AllocateWithTTL(owner string, ttl time.Duration) (Lease, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.reclaim(now)
+
+	ip, err := l.alloc.Allocate()
+	if err != nil {
+		return Lease{}, err
+	}
+
+	ls := &lease{
+		ip:     ipToXIP(ip),
+		owner:  owner,
+		expiry: now.Add(ttl),
+	}
+	l.leases[ls.ip] = ls
+	heap.Push(&l.expiries, ls)
+
+	return ls.export(), nil
+}
+
+// Renew extends the lease of net.IP ip, which expires after ttl from now.
+// The error errNotAllocated will be returned when ip has no lease, or its
+// lease has expired.
+func (l *LeaseAllocator) Renew(ip net.IP, ttl time.Duration) (Lease, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.reclaim(now)
+
+	ls, ok := l.leases[ipToXIP(ip)]
+	if !ok {
+		return Lease{}, fmt.Errorf("%w: %s", errNotAllocated, ip)
+	}
+	ls.expiry = now.Add(ttl)
+	heap.Fix(&l.expiries, ls.index)
+
+	return ls.export(), nil
+}
+
+// Release ends the lease of net.IP ip, and releases ip back to the
+// Allocator. The error errNotAllocated will be returned when ip has no
+// lease, or its lease has expired.
+func (l *LeaseAllocator) Release(ip net.IP) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.reclaim(l.clock.Now())
+
+	w := ipToXIP(ip)
+	ls, ok := l.leases[w]
+	if !ok {
+		return fmt.Errorf("%w: %s", errNotAllocated, ip)
+	}
+	delete(l.leases, w)
+	heap.Remove(&l.expiries, ls.index)
+
+	return l.alloc.Release(ip)
+}
+
+// Lease returns the lease of net.IP ip, ok is false if ip has no lease, or
+// its lease has expired.
+func (l *LeaseAllocator) Lease(ip net.IP) (ls Lease, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.reclaim(l.clock.Now())
+
+	if ls, ok := l.leases[ipToXIP(ip)]; ok {
+		return ls.export(), true
+	}
+
+	return Lease{}, false
+}
+
+// Leases returns all the leases that have not expired, in ascending order
+// of their expiry time.
+func (l *LeaseAllocator) Leases() []Lease {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.reclaim(l.clock.Now())
+
+	h := append(leaseHeap(nil), l.expiries...)
+	sort.SliceStable(h, func(i, j int) bool {
+		return h[i].expiry.Before(h[j].expiry)
+	})
+
+	res := make([]Lease, 0, len(h))
+	for _, ls := range h {
+		res = append(res, ls.export())
+	}
+
+	return res
+}
+
+// Reclaim releases the IP addresses of the expired leases back to the
+// Allocator, and returns them as IPRanges. It is not necessary to call
+// Reclaim, as the other methods of LeaseAllocator reclaim the expired
+// leases anyway.
+func (l *LeaseAllocator) Reclaim() *IPRanges {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.reclaim(l.clock.Now())
+}
+
+// reclaim releases the IP addresses of the leases expired at time now.
+func (l *LeaseAllocator) reclaim(now time.Time) *IPRanges {
+	var expired []ipRange
+	for len(l.expiries) > 0 && !l.expiries[0].expiry.After(now) {
+		ls := heap.Pop(&l.expiries).(*lease)
+		delete(l.leases, ls.ip)
+		expired = append(expired, ipRange{start: ls.ip, end: ls.ip})
+	}
+
+	rs := &IPRanges{
+		version: l.alloc.version,
+		ranges:  mergeRanges(expired),
+		merged:  true,
+	}
+	l.alloc.releaseRanges(rs.ranges)
+
+	return rs
+}
+
+// releaseRanges releases the IP addresses of merged ranges, which must all
+// have been allocated, back to Allocator a. The free IP addresses are
+// merged with ranges just like IPRanges.Union does.
+func (a *Allocator) releaseRanges(ranges []ipRange) {
+	if len(ranges) == 0 {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.used = diffRanges(a.used, ranges)
+	a.free = mergeRanges(append(a.free, ranges...))
+}
+
+// lease is a Lease whose IP address is an xIP, and index is its index in
+// leaseHeap.
+type lease struct {
+	ip     xIP
+	owner  string
+	expiry time.Time
+	index  int
+}
+
+// export converts lease ls as Lease.
+func (ls *lease) export() Lease {
+	return Lease{
+		IP:     ls.ip.toIP(),
+		Owner:  ls.owner,
+		Expiry: ls.expiry,
+	}
+}
+
+// leaseHeap implements heap.Interface, which orders leases by their expiry
+// time.
+type leaseHeap []*lease
+
+func (h leaseHeap) Len() int {
+	return len(h)
+}
+
+func (h leaseHeap) Less(i, j int) bool {
+	return h[i].expiry.Before(h[j].expiry)
+}
+
+func (h leaseHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *leaseHeap) Push(x any) {
+	ls := x.(*lease)
+	ls.index = len(*h)
+	*h = append(*h, ls)
+}
+
+func (h *leaseHeap) Pop() any {
+	old := *h
+	n := len(old)
+	ls := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+
+	return ls
+}
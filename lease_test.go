@@ -0,0 +1,127 @@
+package iprange
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock whose time only moves when it is advanced.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestLeaseAllocator(t *testing.T) {
+	t.Parallel()
+	pool, err := Parse("172.18.0.1-3")
+	if err != nil {
+		t.Fatalf("Parse() err %q", err)
+	}
+
+	a := NewAllocator(pool)
+	if err := a.AllocateSpecific(net.ParseIP("172.18.0.3")); err != nil {
+		t.Fatalf("Allocator(%v).AllocateSpecific(172.18.0.3) err %q", pool, err)
+	}
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := NewLeaseAllocator(a, clock)
+
+	ls1, err := l.AllocateWithTTL("a", time.Minute)
+	if err != nil {
+		t.Fatalf("LeaseAllocator.AllocateWithTTL(a) err %q", err)
+	}
+	ls2, err := l.AllocateWithTTL("b", 2*time.Minute)
+	if err != nil {
+		t.Fatalf("LeaseAllocator.AllocateWithTTL(b) err %q", err)
+	}
+	if ls1.IP.String() != "172.18.0.1" || ls1.Owner != "a" || !ls1.Expiry.Equal(clock.now.Add(time.Minute)) {
+		t.Fatalf("LeaseAllocator.AllocateWithTTL(a) = %+v", ls1)
+	}
+	if _, err := l.AllocateWithTTL("c", time.Minute); !errors.Is(err, errPoolExhausted) {
+		t.Fatalf("LeaseAllocator.AllocateWithTTL(c) err %v, want %v", err, errPoolExhausted)
+	}
+
+	// The lease of a is renewed, and outlives the lease of b.
+	clock.advance(30 * time.Second)
+	if _, err := l.Renew(ls1.IP, 5*time.Minute); err != nil {
+		t.Fatalf("LeaseAllocator.Renew(%v) err %q", ls1.IP, err)
+	}
+	clock.advance(2 * time.Minute)
+	if _, ok := l.Lease(ls2.IP); ok {
+		t.Fatalf("LeaseAllocator.Lease(%v) ok, want expired", ls2.IP)
+	}
+	if _, err := l.Renew(ls2.IP, time.Minute); !errors.Is(err, errNotAllocated) {
+		t.Fatalf("LeaseAllocator.Renew(%v) err %v, want %v", ls2.IP, err, errNotAllocated)
+	}
+	if a.Has(ls2.IP) {
+		t.Fatalf("Allocator.Has(%v) = true after the lease expired", ls2.IP)
+	}
+	if ls, ok := l.Lease(ls1.IP); !ok || ls.Owner != "a" {
+		t.Fatalf("LeaseAllocator.Lease(%v) = %+v, %v, want the lease of a", ls1.IP, ls, ok)
+	}
+
+	ls3, err := l.AllocateWithTTL("c", time.Minute)
+	if err != nil || !ls3.IP.Equal(ls2.IP) {
+		t.Fatalf("LeaseAllocator.AllocateWithTTL(c) = %+v, %v, want %v", ls3, err, ls2.IP)
+	}
+	if leases := l.Leases(); len(leases) != 2 || leases[0].Owner != "c" || leases[1].Owner != "a" {
+		t.Fatalf("LeaseAllocator.Leases() = %+v, want the leases of c and a", leases)
+	}
+
+	if err := l.Release(ls3.IP); err != nil {
+		t.Fatalf("LeaseAllocator.Release(%v) err %q", ls3.IP, err)
+	}
+	if err := l.Release(ls3.IP); !errors.Is(err, errNotAllocated) {
+		t.Fatalf("LeaseAllocator.Release(%v) err %v, want %v", ls3.IP, err, errNotAllocated)
+	}
+
+	// The IP address allocated directly never expires.
+	clock.advance(time.Hour)
+	if rs := l.Reclaim(); rs.String() != "172.18.0.1" {
+		t.Fatalf("LeaseAllocator.Reclaim() = %v, want 172.18.0.1", rs)
+	}
+	if used := a.Allocated().String(); used != "172.18.0.3" {
+		t.Fatalf("Allocator.Allocated() = %v, want 172.18.0.3", used)
+	}
+}
+
+func TestLeaseAllocatorReclaim(t *testing.T) {
+	t.Parallel()
+	pool, err := Parse("fd00::/120")
+	if err != nil {
+		t.Fatalf("Parse() err %q", err)
+	}
+
+	a := NewAllocator(pool)
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := NewLeaseAllocator(a, clock)
+	for i := 0; i < 256; i++ {
+		if _, err := l.AllocateWithTTL("owner", time.Duration(i%3+1)*time.Minute); err != nil {
+			t.Fatalf("LeaseAllocator.AllocateWithTTL() err %q", err)
+		}
+	}
+
+	// The expired IP addresses are merged back into the free ones.
+	clock.advance(2 * time.Minute)
+	if rs := l.Reclaim(); rs.Size().Int64() != 171 {
+		t.Fatalf("LeaseAllocator.Reclaim() = %v, want 171 IP addresses", rs)
+	}
+	clock.advance(time.Minute)
+	if rs := l.Reclaim(); rs.Size().Int64() != 85 {
+		t.Fatalf("LeaseAllocator.Reclaim() = %v, want 85 IP addresses", rs)
+	}
+	if free := a.Free(); free.Int64() != 256 {
+		t.Fatalf("Allocator.Free() = %v, want 256", free)
+	}
+	if len(a.free) != 1 {
+		t.Fatalf("Allocator has free IP ranges %v, want them merged", a.free)
+	}
+}
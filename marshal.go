@@ -0,0 +1,156 @@
+package iprange
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// MarshalText implements encoding.TextMarshaler. The text form of IPRanges
+// is its IP range strings separated by commas, for instance:
+//
+//	172.18.0.1,172.18.0.0/24,172.18.1.1-172.18.1.10
+//
+// MarshalText has a value receiver, so that an IPRanges held by value, such
+// as a field of a config struct, is marshaled as well.
+func (rr IPRanges) MarshalText() ([]byte, error) {
+	return []byte(strings.Join(rr.Strings(), ",")), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It parses IP range
+// strings separated by commas, see MarshalText, and the whitespace around
+// each IP range string is ignored. The commas within the comma lists of the
+// IPv4 octet notation are kept, so 192.168.0,2,4.0/24,10.0.0.1 is two IP
+// range strings.
+//
+// UnmarshalText is meant for decoding into a new IPRanges, as it is the
+// only method that changes IPRanges rr.
+func (rr *IPRanges) UnmarshalText(text []byte) error {
+	rs, err := Parse(splitText(text)...)
+	if err != nil {
+		return err
+	}
+	*rr = *rs
+
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. IPRanges is encoded as a JSON
+// array of IP range strings. Like MarshalText, it has a value receiver.
+func (rr IPRanges) MarshalJSON() ([]byte, error) {
+	return json.Marshal(rr.Strings())
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It accepts either a JSON
+// array of IP range strings, or a single JSON string in the text form of
+// IPRanges, see MarshalText. JSON null is a no-op.
+//
+// UnmarshalJSON is meant for decoding into a new IPRanges, as it is the
+// only method that changes IPRanges rr.
+func (rr *IPRanges) UnmarshalJSON(data []byte) error {
+	ss, err := unmarshalJSONStrings(data)
+	if err != nil || ss == nil {
+		return err
+	}
+
+	rs, err := Parse(ss...)
+	if err != nil {
+		return err
+	}
+	*rr = *rs
+
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler, see IPRanges.MarshalText.
+func (ds DualStackRanges) MarshalText() ([]byte, error) {
+	return []byte(strings.Join(ds.Strings(), ",")), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, see
+// IPRanges.UnmarshalText.
+func (ds *DualStackRanges) UnmarshalText(text []byte) error {
+	rs, err := ParseDualStack(splitText(text)...)
+	if err != nil {
+		return err
+	}
+	*ds = *rs
+
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, see IPRanges.MarshalJSON.
+func (ds DualStackRanges) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ds.Strings())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, see IPRanges.UnmarshalJSON.
+func (ds *DualStackRanges) UnmarshalJSON(data []byte) error {
+	ss, err := unmarshalJSONStrings(data)
+	if err != nil || ss == nil {
+		return err
+	}
+
+	rs, err := ParseDualStack(ss...)
+	if err != nil {
+		return err
+	}
+	*ds = *rs
+
+	return nil
+}
+
+// splitText splits the text form of IPRanges into IP range strings. A
+// comma separates IP range strings, unless it is within a comma list of the
+// IPv4 octet notation, i.e. the IP range string before it has less than 4
+// octets so far, like 192.168.0,2.0, or the text after it has no dots or
+// colons, like 10.0.0.1,3.
+func splitText(text []byte) []string {
+	s := strings.TrimSpace(string(text))
+	if s == "" {
+		return nil
+	}
+
+	var ss []string
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if n := len(ss); n > 0 && (isPartialOctets(ss[n-1]) || f != "" && !strings.ContainsAny(f, ".:")) {
+			ss[n-1] += "," + f
+			continue
+		}
+		ss = append(ss, f)
+	}
+
+	return ss
+}
+
+// isPartialOctets reports whether IP range string r is the beginning of an
+// IPv4 address in octet notation, which has less than 4 octets.
+func isPartialOctets(r string) bool {
+	return r != "" && !strings.Contains(r, ":") && strings.Count(r, ".") < 3
+}
+
+// unmarshalJSONStrings decodes data, which is either a JSON array of
+// strings or a JSON string in the text form of IPRanges, as IP range
+// strings. It returns nil for JSON null.
+func unmarshalJSONStrings(data []byte) ([]string, error) {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+
+	if len(data) != 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, err
+		}
+		return append([]string{}, splitText([]byte(s))...), nil
+	}
+
+	ss := []string{}
+	if err := json.Unmarshal(data, &ss); err != nil {
+		return nil, err
+	}
+
+	return ss, nil
+}
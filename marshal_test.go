@@ -0,0 +1,165 @@
+package iprange
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var ipRangesMarshalTests = []struct {
+	name   string
+	ranges []string
+	text   string
+	json   string
+}{
+	{
+		name:   "IPv4",
+		ranges: []string{"172.18.0.1", "172.18.0.0/24", "172.18.1.1-10"},
+		text:   "172.18.0.1,172.18.0.0/24,172.18.1.1-172.18.1.10",
+		json:   `["172.18.0.1","172.18.0.0/24","172.18.1.1-172.18.1.10"]`,
+	},
+	{
+		name:   "IPv6",
+		ranges: []string{"fd00::/64", "fd00::1-a"},
+		text:   "fd00::/64,fd00::1-fd00::a",
+		json:   `["fd00::/64","fd00::1-fd00::a"]`,
+	},
+	{"empty", []string{}, "", `[]`},
+}
+
+func TestIPRangesMarshal(t *testing.T) {
+	t.Parallel()
+	for _, test := range ipRangesMarshalTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ranges, err := Parse(test.ranges...)
+			if err != nil {
+				t.Fatalf("Parse(%q) err %q", test.ranges, err)
+			}
+
+			text, err := ranges.MarshalText()
+			if err != nil || string(text) != test.text {
+				t.Fatalf("IPRanges(%v).MarshalText() = %q, %v, want %q", ranges, text, err, test.text)
+			}
+			data, err := json.Marshal(ranges)
+			if err != nil || string(data) != test.json {
+				t.Fatalf("json.Marshal(IPRanges(%v)) = %s, %v, want %s", ranges, data, err, test.json)
+			}
+
+			var fromText IPRanges
+			if err := fromText.UnmarshalText(text); err != nil {
+				t.Fatalf("IPRanges.UnmarshalText(%q) err %q", text, err)
+			}
+			if !fromText.Equal(ranges) {
+				t.Fatalf("IPRanges.UnmarshalText(%q) = %v, want %v", text, &fromText, ranges)
+			}
+			var fromJSON IPRanges
+			if err := json.Unmarshal(data, &fromJSON); err != nil {
+				t.Fatalf("json.Unmarshal(%s) err %q", data, err)
+			}
+			if !fromJSON.Equal(ranges) {
+				t.Fatalf("json.Unmarshal(%s) = %v, want %v", data, &fromJSON, ranges)
+			}
+		})
+	}
+}
+
+var ipRangesUnmarshalJSONTests = []struct {
+	name string
+	data string
+	want []string
+	err  error
+}{
+	{"array", `["172.18.0.1", "172.18.0.2-3"]`, []string{"172.18.0.1", "172.18.0.2/31"}, nil},
+	{"string", `"172.18.0.1, 172.18.0.2-3"`, []string{"172.18.0.1", "172.18.0.2/31"}, nil},
+	{"octet lists", `"192.168.0,2.0/24, 10.0.0.1,3, 10.0.0.5"`, []string{"192.168.0.0/24", "192.168.2.0/24", "10.0.0.1", "10.0.0.3", "10.0.0.5"}, nil},
+	{"empty string", `""`, []string{}, nil},
+	{"null", `null`, []string{"fd00::1"}, nil},
+	{"invalid IP range", `["172.18.0.1", "172.18.0.300"]`, nil, errInvalidIPRangeFormat},
+	{"invalid string", `"172.18.0.1,,172.18.0.2"`, nil, errInvalidIPRangeFormat},
+	{"dual-stack", `["172.18.0.1", "fd00::1"]`, nil, errDualStackIPRanges},
+}
+
+func TestIPRangesUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+	for _, test := range ipRangesUnmarshalJSONTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ranges, _ := Parse("fd00::1")
+			err := json.Unmarshal([]byte(test.data), ranges)
+			if err != nil || test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("json.Unmarshal(%s) err %v, want %v", test.data, err, test.err)
+				}
+				var perr *ParseError
+				if !errors.As(err, &perr) {
+					t.Fatalf("json.Unmarshal(%s) err %v, want *ParseError", test.data, err)
+				}
+				return
+			}
+
+			if ss := ranges.Strings(); !cmp.Equal(ss, test.want) {
+				t.Fatalf("json.Unmarshal(%s) = %q, want %q", test.data, ss, test.want)
+			}
+		})
+	}
+}
+
+func TestDualStackRangesMarshalJSON(t *testing.T) {
+	t.Parallel()
+	data := []byte(`{"pool":["fd00::/64","172.18.0.0/24"]}`)
+	var config struct {
+		Pool *DualStackRanges `json:"pool"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatalf("json.Unmarshal(%s) err %q", data, err)
+	}
+
+	out, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("json.Marshal(%v) err %q", config.Pool, err)
+	}
+	if want := `{"pool":["172.18.0.0/24","fd00::/64"]}`; string(out) != want {
+		t.Fatalf("json.Marshal(%v) = %s, want %s", config.Pool, out, want)
+	}
+}
+
+func TestMarshalJSONValueField(t *testing.T) {
+	t.Parallel()
+	var config struct {
+		R IPRanges
+		D DualStackRanges
+	}
+	config.R = *mustParse("172.18.0.0/24")
+	ds, err := ParseDualStack("fd00::/64", "172.18.0.1")
+	if err != nil {
+		t.Fatalf("ParseDualStack() err %q", err)
+	}
+	config.D = *ds
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("json.Marshal(%v) err %q", config, err)
+	}
+	if want := `{"R":["172.18.0.0/24"],"D":["172.18.0.1","fd00::/64"]}`; string(data) != want {
+		t.Fatalf("json.Marshal(%v) = %s, want %s", config, data, want)
+	}
+}
+
+func TestUnmarshalTextOctetLists(t *testing.T) {
+	t.Parallel()
+	rr := mustParse("192.168.0,2,4.0/24", "10.0.0.1,3")
+	text := "192.168.0,2,4.0/24,10.0.0.1,3"
+
+	var got IPRanges
+	if err := got.UnmarshalText([]byte(text)); err != nil {
+		t.Fatalf("IPRanges.UnmarshalText(%q) err %q", text, err)
+	}
+	if !got.Equal(rr) {
+		t.Fatalf("IPRanges.UnmarshalText(%q) = %v, want %v", text, &got, rr)
+	}
+}
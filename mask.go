@@ -0,0 +1,138 @@
+package iprange
+
+import (
+	"math/bits"
+	"net"
+	"strings"
+)
+
+// isMaskNotation reports whether IP range string r is an IP address with a
+// mask, either after a slash or a space, and splits r into both. wildcard
+// reports whether the mask is after a space, where it may be a wildcard
+// mask.
+func isMaskNotation(r string) (addr, mask string, wildcard, ok bool) {
+	if before, after, found := strings.Cut(r, "/"); found && strings.ContainsAny(after, ".:") {
+		return before, after, false, true
+	}
+
+	if fields := strings.Fields(r); len(fields) == 2 {
+		return fields[0], fields[1], true, true
+	}
+
+	return "", "", false, false
+}
+
+// parseMask parses IP address addr with mask, which is a netmask after a
+// slash, and either a netmask or a wildcard mask as in ACLs after a space:
+//
+//	10.0.0.0/255.255.255.0  10.0.0.0 255.255.0.0  10.0.0.0 0.0.0.255
+//
+// A netmask is leading ones followed by zeros, which is equivalent to the
+// prefix length of the ones, so 0.0.0.0/0.0.0.0 is 0.0.0.0/0, and any
+// other mask after a slash is a bad mask. Like a prefix length, it keeps
+// addr as the start, so 10.0.0.5/255.255.255.0 is 10.0.0.5-10.0.0.255.
+//
+// A wildcard mask has ones marking the bits of addr to ignore, so
+// 10.0.0.5 0.0.0.255 is 10.0.0.0/24. It may be non-contiguous like
+// 0.0.1.255, which results in multiple ipRanges, but then the bits of addr
+// under the ones above the trailing ones must be zeros, as ACLs write them.
+// This keeps two IP addresses like 10.0.0.1 10.0.0.5 from being taken as
+// an IP address with a wildcard mask.
+//
+// After a space, a mask which fits both is taken as a netmask, unless it is
+// all zeros or all ones, which are taken as wildcard masks as ACLs do, so
+// 10.0.0.1 0.0.0.0 is a single IP address, and 0.0.0.0 255.255.255.255 is
+// any IP address.
+func parseMask(r, addr, mask string, wildcard bool) ([]ipRange, *ParseError) {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return nil, newParseError(r, ReasonBadIP)
+	}
+	m := net.ParseIP(mask)
+	if m == nil {
+		return nil, newParseError(r, ReasonBadMask)
+	}
+
+	a, w := ipToXIP(ip), ipToXIP(m)
+	if a.version() != w.version() {
+		return nil, newParseError(r, ReasonFamilyMismatch)
+	}
+	n := a.bitLen()
+
+	// 10.0.0.0/255.255.255.0
+	// 10.0.0.0 255.255.255.0
+	host := w.num.not().and(lowBits(n))
+	isNetmask := host.and(host.addOne()).isZero()
+	if isNetmask && (!wildcard || !host.isZero() && host != lowBits(n)) {
+		return []ipRange{{
+			start: a,
+			end:   xIP{a.num.or(host), a.version()},
+		}}, nil
+	}
+	if !wildcard {
+		return nil, newParseError(r, ReasonBadMask)
+	}
+
+	// 10.0.0.0 0.0.1.255
+	// The trailing ones of the wildcard mask make contiguous blocks, while
+	// the other ones are enumerated.
+	t := minN(w.num.not().trailingZeros(), n)
+	high := w.num.and(lowBits(t).not())
+	if !a.num.and(high).isZero() {
+		return nil, newParseError(r, ReasonBadMask)
+	}
+	if k := bits.OnesCount64(high.hi) + bits.OnesCount64(high.lo); k >= bits.UintSize-1 || 1<<k > maxExpandedRanges {
+		return nil, newParseError(r, ReasonTooManyRanges)
+	}
+
+	base := a.num.and(w.num.not())
+	var ranges []ipRange
+	for sub := (uint128{}); ; sub = sub.sub(high).and(high) {
+		start := base.or(sub)
+		ranges = append(ranges, ipRange{
+			start: xIP{start, a.version()},
+			end:   xIP{start.or(lowBits(t)), a.version()},
+		})
+		if sub == high {
+			break
+		}
+	}
+
+	return ranges, nil
+}
+
+// WildcardMasks returns IPRanges rr as ACL entries with wildcard masks,
+// which are the CIDRs of rr after merged, for instance:
+//
+//	10.0.0.0 0.0.0.255
+//	10.0.1.1 0.0.0.0
+//
+// Each entry can be parsed back by Parse.
+func (rr *IPRanges) WildcardMasks() []string {
+	return wildcardMasks(rr.Merge().ranges)
+}
+
+// WildcardMasks returns DualStackRanges ds as ACL entries with wildcard
+// masks, IPv4 entries first and IPv6 entries after, see
+// IPRanges.WildcardMasks.
+func (ds *DualStackRanges) WildcardMasks() []string {
+	return wildcardMasks(ds.Merge().ranges())
+}
+
+// wildcardMasks returns the CIDRs of ranges as ACL entries with wildcard
+// masks.
+func wildcardMasks(ranges []ipRange) []string {
+	var entries []string
+	iter := newCIDRIterator(ranges)
+	for {
+		ip, ones, ok := iter.next()
+		if !ok {
+			break
+		}
+
+		mask := xIP{lowBits(ip.bitLen() - ones), ip.version()}
+		entries = append(entries, ip.String()+" "+mask.String())
+	}
+
+	return entries
+}
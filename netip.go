@@ -0,0 +1,207 @@
+package iprange
+
+import (
+	"math/big"
+	"net/netip"
+)
+
+// FromPrefixes converts a set of netip.Prefix as IPRanges. Each prefix is
+// masked first, so 172.18.0.1/24 is equivalent to 172.18.0.0/24, and an
+// IPv4-mapped IPv6 prefix is treated as the IPv4 prefix it maps to.
+//
+// The error errInvalidIPRangeFormat will be returned when one of prefix
+// is invalid. And dual-stack IP ranges are not allowed, the error
+// errDualStackIPRanges occurs when there are both IPv4 and IPv6 prefixes.
+// Either error is wrapped in a *ParseError like Parse, whose Index is the
+// index of the prefix.
+func FromPrefixes(prefixes ...netip.Prefix) (*IPRanges, error) {
+	if len(prefixes) == 0 {
+		return &IPRanges{}, nil
+	}
+
+	version := Unknown
+	ranges := make([]ipRange, 0, len(prefixes))
+	for i, p := range prefixes {
+		r, err := prefixToRange(p)
+		if err != nil {
+			err.Index = i
+			return nil, err
+		}
+
+		if i == 0 {
+			version = r.start.version()
+		}
+
+		if r.start.version() != version {
+			return nil, &ParseError{
+				Index:  i,
+				Input:  p.String(),
+				Reason: ReasonFamilyMismatch,
+				err:    errDualStackIPRanges,
+			}
+		}
+		ranges = append(ranges, r)
+	}
+
+	return &IPRanges{
+		version: version,
+		ranges:  ranges,
+	}, nil
+}
+
+// FromAddrRange converts the IP range from netip.Addr start to end as
+// IPRanges. IPv4-mapped IPv6 addresses are treated as IPv4 addresses.
+//
+// The error errInvalidIPRangeFormat will be returned when start or end is
+// invalid, or start exceeds end. And the error errDualStackIPRanges occurs
+// when start and end are of different IP versions. Either error is wrapped
+// in a *ParseError like Parse, whose Input is start-end.
+func FromAddrRange(start, end netip.Addr) (*IPRanges, error) {
+	r := start.String() + "-" + end.String()
+	if !start.IsValid() {
+		return nil, newParseError(r, ReasonBadStartIP)
+	}
+	if !end.IsValid() {
+		return nil, newParseError(r, ReasonBadEndIP)
+	}
+
+	s, e := addrToXIP(start), addrToXIP(end)
+	if s.version() != e.version() {
+		return nil, &ParseError{
+			Input:  r,
+			Reason: ReasonFamilyMismatch,
+			err:    errDualStackIPRanges,
+		}
+	}
+
+	if e.cmp(s) < 0 {
+		return nil, newParseError(r, ReasonReversedRange)
+	}
+
+	return &IPRanges{
+		version: s.version(),
+		ranges: []ipRange{
+			{
+				start: s,
+				end:   e,
+			},
+		},
+	}, nil
+}
+
+// ContainsAddr reports whether IPRanges rr contain netip.Addr addr. If rr
+// is IPv4 and addr is IPv6, then it is also considered not contained, and
+// vice versa. IPv4-mapped IPv6 addresses are treated as IPv4 addresses.
+func (rr *IPRanges) ContainsAddr(addr netip.Addr) bool {
+	return rr.contains(addrToXIP(addr))
+}
+
+// ContainsAddr reports whether DualStackRanges ds contain netip.Addr addr.
+func (ds *DualStackRanges) ContainsAddr(addr netip.Addr) bool {
+	w := addrToXIP(addr)
+	switch w.version() {
+	case IPv4:
+		return ds.IPv4().contains(w)
+	case IPv6:
+		return ds.IPv6().contains(w)
+	default:
+		return false
+	}
+}
+
+type addrIterator struct {
+	iter *ipIterator
+}
+
+// AddrIterator generates a new iterator for scanning IP addresses as
+// netip.Addr.
+func (rr *IPRanges) AddrIterator() *addrIterator {
+	return &addrIterator{
+		iter: rr.IPIterator(),
+	}
+}
+
+// AddrIterator generates a new iterator for scanning IP addresses as
+// netip.Addr, IPv4 addresses first and IPv6 addresses after.
+func (ds *DualStackRanges) AddrIterator() *addrIterator {
+	return &addrIterator{
+		iter: ds.IPIterator(),
+	}
+}
+
+// Next returns the next IP address. If the addrIterator has been exhausted,
+// return the zero netip.Addr, which is invalid.
+func (ai *addrIterator) Next() netip.Addr {
+	ip, _ := ai.iter.next()
+
+	return ip.toAddr()
+}
+
+// NextN returns the next nth IP address. If the addrIterator has been
+// exhausted, return the zero netip.Addr, which is invalid. If n <= 0, it is
+// equivalent to NextN(1).
+func (ai *addrIterator) NextN(n *big.Int) netip.Addr {
+	ip, _ := ai.iter.nextN(n)
+
+	return ip.toAddr()
+}
+
+// Reset resets IP address iterator.
+func (ai *addrIterator) Reset() {
+	ai.iter.Reset()
+}
+
+type prefixIterator struct {
+	iter *cidrIterator
+}
+
+// PrefixIterator generates a new iterator for scanning CIDR as
+// netip.Prefix.
+func (rr *IPRanges) PrefixIterator() *prefixIterator {
+	return &prefixIterator{
+		iter: rr.CIDRIterator(),
+	}
+}
+
+// PrefixIterator generates a new iterator for scanning CIDR as
+// netip.Prefix, IPv4 CIDR first and IPv6 CIDR after.
+func (ds *DualStackRanges) PrefixIterator() *prefixIterator {
+	return &prefixIterator{
+		iter: ds.CIDRIterator(),
+	}
+}
+
+// Next returns the next CIDR. If the prefixIterator has been exhausted,
+// return the zero netip.Prefix, which is invalid.
+func (pi *prefixIterator) Next() netip.Prefix {
+	ip, ones, ok := pi.iter.next()
+	if !ok {
+		return netip.Prefix{}
+	}
+
+	return netip.PrefixFrom(ip.toAddr(), ones)
+}
+
+// prefixToRange converts netip.Prefix p to ipRange. A *ParseError wrapping
+// the error errInvalidIPRangeFormat will be returned when p is invalid.
+func prefixToRange(p netip.Prefix) (ipRange, *ParseError) {
+	if !p.Addr().IsValid() {
+		return ipRange{}, newParseError(p.String(), ReasonBadIP)
+	}
+	if !p.IsValid() {
+		return ipRange{}, newParseError(p.String(), ReasonBadPrefixLength)
+	}
+
+	p = p.Masked()
+	if p.Addr().Is4In6() && p.Bits() >= 96 {
+		p = netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96)
+	}
+
+	start := addrToXIP(p.Addr())
+	host := lowBits(start.bitLen() - p.Bits())
+
+	return ipRange{
+		start: start,
+		end:   xIP{start.num.or(host), start.ver},
+	}, nil
+}
@@ -0,0 +1,240 @@
+package iprange
+
+import (
+	"errors"
+	"math/big"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var fromPrefixesTests = []struct {
+	name     string
+	prefixes []netip.Prefix
+	want     *IPRanges
+	err      *ParseError
+}{
+	{
+		name: "IPv4",
+		prefixes: []netip.Prefix{
+			netip.MustParsePrefix("172.18.0.0/24"),
+			netip.MustParsePrefix("172.18.1.1/31"),
+			netip.MustParsePrefix("::ffff:172.18.2.0/120"),
+		},
+		want: &IPRanges{
+			version: IPv4,
+			ranges: []ipRange{
+				{
+					start: ipToXIP(net.IPv4(172, 18, 0, 0).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 255).To4()),
+				},
+				{
+					start: ipToXIP(net.IPv4(172, 18, 1, 0).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 1, 1).To4()),
+				},
+				{
+					start: ipToXIP(net.IPv4(172, 18, 2, 0).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 2, 255).To4()),
+				},
+			},
+		},
+		err: nil,
+	},
+	{
+		name: "IPv6",
+		prefixes: []netip.Prefix{
+			netip.MustParsePrefix("fd00::/64"),
+			netip.MustParsePrefix("fd00::1/128"),
+		},
+		want: &IPRanges{
+			version: IPv6,
+			ranges: []ipRange{
+				{
+					start: ipToXIP(net.ParseIP("fd00::")),
+					end:   ipToXIP(net.ParseIP("fd00::ffff:ffff:ffff:ffff")),
+				},
+				{
+					start: ipToXIP(net.ParseIP("fd00::1")),
+					end:   ipToXIP(net.ParseIP("fd00::1")),
+				},
+			},
+		},
+		err: nil,
+	},
+	{"empty", []netip.Prefix{}, &IPRanges{}, nil},
+	{"invalid", []netip.Prefix{{}}, nil, &ParseError{0, "invalid Prefix", ReasonBadIP, errInvalidIPRangeFormat}},
+	{
+		name: "bad prefix length",
+		prefixes: []netip.Prefix{
+			netip.MustParsePrefix("172.18.0.0/24"),
+			netip.PrefixFrom(netip.MustParseAddr("172.18.1.0"), 33),
+		},
+		want: nil,
+		err:  &ParseError{1, "invalid Prefix", ReasonBadPrefixLength, errInvalidIPRangeFormat},
+	},
+	{
+		name: "dual-stack",
+		prefixes: []netip.Prefix{
+			netip.MustParsePrefix("172.18.0.0/24"),
+			netip.MustParsePrefix("fd00::/64"),
+		},
+		want: nil,
+		err:  &ParseError{1, "fd00::/64", ReasonFamilyMismatch, errDualStackIPRanges},
+	},
+}
+
+func TestFromPrefixes(t *testing.T) {
+	t.Parallel()
+	for _, test := range fromPrefixesTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ranges, err := FromPrefixes(test.prefixes...)
+			if test.err != nil {
+				var parseErr *ParseError
+				if !errors.As(err, &parseErr) || *parseErr != *test.err {
+					t.Fatalf("FromPrefixes(%v) err %#v, want %#v", test.prefixes, err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FromPrefixes(%v) err %q", test.prefixes, err)
+			}
+			if !cmp.Equal(ranges, test.want) {
+				t.Fatalf("FromPrefixes(%v) = %v, want %v", test.prefixes, ranges, test.want)
+			}
+		})
+	}
+}
+
+var fromAddrRangeTests = []struct {
+	name  string
+	start netip.Addr
+	end   netip.Addr
+	want  string
+	err   *ParseError
+}{
+	{"IPv4", netip.MustParseAddr("172.18.0.1"), netip.MustParseAddr("172.18.0.10"), "172.18.0.1-172.18.0.10", nil},
+	{"IPv6", netip.MustParseAddr("fd00::"), netip.MustParseAddr("fd00::ff"), "fd00::/120", nil},
+	{"invalid start", netip.Addr{}, netip.MustParseAddr("172.18.0.10"), "", &ParseError{0, "invalid IP-172.18.0.10", ReasonBadStartIP, errInvalidIPRangeFormat}},
+	{"invalid end", netip.MustParseAddr("172.18.0.1"), netip.Addr{}, "", &ParseError{0, "172.18.0.1-invalid IP", ReasonBadEndIP, errInvalidIPRangeFormat}},
+	{"start exceeds end", netip.MustParseAddr("172.18.0.10"), netip.MustParseAddr("172.18.0.1"), "", &ParseError{0, "172.18.0.10-172.18.0.1", ReasonReversedRange, errInvalidIPRangeFormat}},
+	{"dual-stack", netip.MustParseAddr("172.18.0.1"), netip.MustParseAddr("fd00::1"), "", &ParseError{0, "172.18.0.1-fd00::1", ReasonFamilyMismatch, errDualStackIPRanges}},
+}
+
+func TestFromAddrRange(t *testing.T) {
+	t.Parallel()
+	for _, test := range fromAddrRangeTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ranges, err := FromAddrRange(test.start, test.end)
+			if test.err != nil {
+				var parseErr *ParseError
+				if !errors.As(err, &parseErr) || *parseErr != *test.err {
+					t.Fatalf("FromAddrRange(%v, %v) err %#v, want %#v", test.start, test.end, err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FromAddrRange(%v, %v) err %q", test.start, test.end, err)
+			}
+			if ranges.String() != test.want {
+				t.Fatalf("FromAddrRange(%v, %v) = %v, want %v", test.start, test.end, ranges, test.want)
+			}
+		})
+	}
+}
+
+var ipRangesContainsAddrTests = []struct {
+	name   string
+	ranges []string
+	addr   netip.Addr
+	want   bool
+}{
+	{"IPv4 contain", []string{"172.18.0.0/24"}, netip.MustParseAddr("172.18.0.1"), true},
+	{"IPv4-mapped contain", []string{"172.18.0.0/24"}, netip.MustParseAddr("::ffff:172.18.0.1"), true},
+	{"IPv6 contain", []string{"fd00::/64"}, netip.MustParseAddr("fd00::1"), true},
+	{"IPv4 not contain", []string{"172.18.0.0/24"}, netip.MustParseAddr("172.19.0.1"), false},
+	{"diff version", []string{"172.18.0.0/24"}, netip.MustParseAddr("fd00::1"), false},
+	{"invalid", []string{"172.18.0.0/24"}, netip.Addr{}, false},
+}
+
+func TestIPRangesContainsAddr(t *testing.T) {
+	t.Parallel()
+	for _, test := range ipRangesContainsAddrTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ranges, err := Parse(test.ranges...)
+			if err != nil {
+				t.Fatalf("Parse(%q) err %q", test.ranges, err)
+			}
+			contains := ranges.ContainsAddr(test.addr)
+			if contains != test.want {
+				t.Fatalf("IPRanges(%v).ContainsAddr(%v) = %v, want %v", ranges, test.addr, contains, test.want)
+			}
+		})
+	}
+}
+
+func TestIPRangesAddrIterator(t *testing.T) {
+	t.Parallel()
+	ranges, err := Parse("172.18.0.1-3", "172.18.0.10")
+	if err != nil {
+		t.Fatalf("Parse() err %q", err)
+	}
+
+	var addrs []netip.Addr
+	iter := ranges.AddrIterator()
+	for {
+		addr := iter.Next()
+		if !addr.IsValid() {
+			break
+		}
+		addrs = append(addrs, addr)
+	}
+	want := []netip.Addr{
+		netip.MustParseAddr("172.18.0.1"),
+		netip.MustParseAddr("172.18.0.2"),
+		netip.MustParseAddr("172.18.0.3"),
+		netip.MustParseAddr("172.18.0.10"),
+	}
+	if !cmp.Equal(addrs, want, cmp.Comparer(func(x, y netip.Addr) bool { return x == y })) {
+		t.Fatalf("IPRanges(%v).AddrIterator() = %v, want %v", ranges, addrs, want)
+	}
+
+	iter.Reset()
+	addr := iter.NextN(big.NewInt(4))
+	if addr != want[3] {
+		t.Fatalf("IPRanges(%v).AddrIterator().NextN(4) = %v, want %v", ranges, addr, want[3])
+	}
+}
+
+func TestIPRangesPrefixIterator(t *testing.T) {
+	t.Parallel()
+	ranges, err := Parse("fd00::/64", "fd00::1-3")
+	if err != nil {
+		t.Fatalf("Parse() err %q", err)
+	}
+
+	var prefixes []netip.Prefix
+	iter := ranges.PrefixIterator()
+	for {
+		prefix := iter.Next()
+		if !prefix.IsValid() {
+			break
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	want := []netip.Prefix{
+		netip.MustParsePrefix("fd00::/64"),
+		netip.MustParsePrefix("fd00::1/128"),
+		netip.MustParsePrefix("fd00::2/127"),
+	}
+	if !cmp.Equal(prefixes, want, cmp.Comparer(func(x, y netip.Prefix) bool { return x == y })) {
+		t.Fatalf("IPRanges(%v).PrefixIterator() = %v, want %v", ranges, prefixes, want)
+	}
+}
@@ -0,0 +1,194 @@
+package iprange
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxExpandedRanges is the maximum number of IP ranges that an IP range
+// string in octet notation or with a wildcard mask may expand to, such as
+// 65536 for 10.*.*.1, which keeps a typo like *.*.*.1 from exhausting the
+// memory.
+const maxExpandedRanges = 1 << 16
+
+// octetRange is an inclusive range of octet values.
+type octetRange struct {
+	lo, hi int
+}
+
+// isOctetNotation reports whether IP range string r is an IPv4 address in
+// octet notation, which has either wildcards, comma lists, or ranges in
+// other octets than the last one. The dash in the last octet alone, such
+// as 172.18.0.1-10, is left to parseRange.
+func isOctetNotation(r string) bool {
+	host, _, _ := strings.Cut(r, "/")
+	if strings.Contains(host, ":") {
+		return false
+	}
+
+	octets := strings.Split(host, ".")
+	if len(octets) != 4 {
+		return false
+	}
+	if strings.ContainsAny(host, "*,") {
+		return true
+	}
+
+	for _, o := range octets[:3] {
+		if strings.Contains(o, "-") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseOctets parses IPv4 address r in octet notation like nmap, where
+// each octet is either a wildcard or a comma list of values and ranges:
+//
+//	10.0.*.1            10.0.0.1, 10.0.1.1, ..., 10.0.255.1
+//	10.0.1-3.1-254      10.0.1.1-254, 10.0.2.1-254, 10.0.3.1-254
+//	192.168.0,2,4.0/24  192.168.0.0/24, 192.168.2.0/24, 192.168.4.0/24
+//
+// A prefix length applies to each IP address expanded, widening it to the
+// CIDR that contains it. As the IP addresses are not contiguous, r results
+// in the minimal merged ipRanges.
+func parseOctets(r string) ([]ipRange, *ParseError) {
+	host, prefix, hasPrefix := strings.Cut(r, "/")
+	ones := 32
+	if hasPrefix {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n < 0 || n > 32 || prefix != strconv.Itoa(n) {
+			return nil, newParseError(r, ReasonBadPrefixLength)
+		}
+		ones = n
+	}
+
+	var specs [4][]octetRange
+	for i, o := range strings.Split(host, ".") {
+		spec, reason := parseOctet(o)
+		if reason != "" {
+			return nil, newParseError(r, reason)
+		}
+		specs[i] = spec
+	}
+
+	// The octets after last are all wildcards, so that each range of the
+	// octet last results in a single ipRange for every combination of the
+	// values of the octets before it.
+	last := 3
+	for last >= 0 && len(specs[last]) == 1 && specs[last][0] == (octetRange{0, 255}) {
+		last--
+	}
+	if last < 0 {
+		return []ipRange{{
+			start: xIP{uint128{}, IPv4},
+			end:   xIP{lowBits(32), IPv4},
+		}}, nil
+	}
+
+	count := len(specs[last])
+	for _, spec := range specs[:last] {
+		n := 0
+		for _, o := range spec {
+			n += o.hi - o.lo + 1
+		}
+		count *= n
+		if count > maxExpandedRanges {
+			return nil, newParseError(r, ReasonTooManyRanges)
+		}
+	}
+
+	shift := 8 * (3 - last)
+	host128 := lowBits(shift).or(lowBits(32 - ones))
+	ranges := make([]ipRange, 0, count)
+	var walk func(i int, prefix uint64)
+	walk = func(i int, prefix uint64) {
+		for _, o := range specs[i] {
+			if i < last {
+				for v := o.lo; v <= o.hi; v++ {
+					walk(i+1, prefix<<8|uint64(v))
+				}
+				continue
+			}
+
+			start := uint128{0, (prefix<<8 | uint64(o.lo)) << shift}
+			end := uint128{0, (prefix<<8 | uint64(o.hi)) << shift}
+			ranges = append(ranges, ipRange{
+				start: xIP{start.and(host128.not()), IPv4},
+				end:   xIP{end.or(host128), IPv4},
+			})
+		}
+	}
+	walk(0, 0)
+
+	return mergeRanges(ranges), nil
+}
+
+// parseOctet parses an octet in octet notation as its ranges in ascending
+// order, which are merged. The reason is returned if o is invalid.
+func parseOctet(o string) ([]octetRange, ParseErrorReason) {
+	if o == "*" {
+		return []octetRange{{0, 255}}, ""
+	}
+
+	var spec []octetRange
+	for _, item := range strings.Split(o, ",") {
+		lo, hi, isRange := strings.Cut(item, "-")
+		if !isRange {
+			hi = lo
+		}
+
+		l, ok := parseOctetValue(lo)
+		if !ok {
+			return nil, ReasonBadIP
+		}
+		h, ok := parseOctetValue(hi)
+		if !ok {
+			return nil, ReasonBadIP
+		}
+		if h < l {
+			return nil, ReasonReversedRange
+		}
+		spec = append(spec, octetRange{l, h})
+	}
+
+	return mergeOctetRanges(spec), ""
+}
+
+// parseOctetValue parses the decimal value of an octet, ok is false if s is
+// not in [0, 255] or has leading zeros, which net.ParseIP rejects as well.
+func parseOctetValue(s string) (v int, ok bool) {
+	if s == "" || len(s) > 3 || (len(s) > 1 && s[0] == '0') {
+		return 0, false
+	}
+
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		v = v*10 + int(c-'0')
+	}
+
+	return v, v <= 255
+}
+
+// mergeOctetRanges sorts and merges octet ranges rs, like mergeRanges.
+func mergeOctetRanges(rs []octetRange) []octetRange {
+	sort.Slice(rs, func(i, j int) bool {
+		return rs[i].lo < rs[j].lo
+	})
+
+	merged := rs[:1]
+	for _, o := range rs[1:] {
+		last := &merged[len(merged)-1]
+		if o.lo <= last.hi+1 {
+			last.hi = maxN(last.hi, o.hi)
+			continue
+		}
+		merged = append(merged, o)
+	}
+
+	return merged
+}
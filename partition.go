@@ -0,0 +1,142 @@
+package iprange
+
+import (
+	"math/big"
+)
+
+// Partition divides IPRanges rr into k disjoint IPRanges of nearly equal
+// sizes, in ascending order, such as to fan a scan out to k workers, each
+// of which scans its own IPRanges. The sizes differ by at most 1, and the
+// IPRanges are empty if rr has less than k IP addresses. It returns nil if
+// k <= 0.
+func (rr *IPRanges) Partition(k int) []*IPRanges {
+	if k <= 0 {
+		return nil
+	}
+
+	weights := make([]int, k)
+	for i := range weights {
+		weights[i] = 1
+	}
+
+	return rr.partition(weights, -1)
+}
+
+// PartitionWeighted is like Partition, but the sizes of the IPRanges are
+// proportional to weights, such as the capacities of the workers. The
+// IPRanges of non-positive weights are empty.
+func (rr *IPRanges) PartitionWeighted(weights []int) []*IPRanges {
+	return rr.partition(weights, -1)
+}
+
+// PartitionAligned is like Partition, but only divides IPRanges rr at the
+// boundaries of the CIDRs whose prefix length is ones, such as /24, so
+// that no such CIDR is split across the IPRanges. Each boundary is moved
+// to the nearest aligned one, so the sizes are only roughly equal, and
+// some of the IPRanges may even be empty.
+func (rr *IPRanges) PartitionAligned(k, ones int) []*IPRanges {
+	if k <= 0 {
+		return nil
+	}
+
+	weights := make([]int, k)
+	for i := range weights {
+		weights[i] = 1
+	}
+
+	return rr.partition(weights, ones)
+}
+
+// partition divides IPRanges rr by weights, at the boundaries of the CIDRs
+// whose prefix length is ones, or anywhere if ones < 0.
+func (rr *IPRanges) partition(weights []int, ones int) []*IPRanges {
+	if len(weights) == 0 {
+		return nil
+	}
+
+	merged := rr.Merge()
+	size := merged.Size()
+	total := new(big.Int)
+	for _, w := range weights {
+		if w > 0 {
+			total.Add(total, big.NewInt(int64(w)))
+		}
+	}
+
+	res := make([]*IPRanges, 0, len(weights))
+	start, sum := new(big.Int), new(big.Int)
+	for _, w := range weights {
+		if w > 0 {
+			sum.Add(sum, big.NewInt(int64(w)))
+		}
+
+		// end is the index of the first IP address of the next IPRanges.
+		end := new(big.Int)
+		if total.Sign() > 0 {
+			end.Mul(size, sum)
+			end.Quo(end, total)
+		}
+		if ones >= 0 {
+			end = merged.alignIndex(end, size, ones)
+		}
+		if end.Cmp(start) < 0 {
+			end.Set(start)
+		}
+
+		rs := &IPRanges{version: rr.version}
+		if end.Cmp(start) > 0 {
+			rs = merged.Slice(start, new(big.Int).Sub(end, bigInt[1]))
+			rs.merged = true
+		}
+		res = append(res, rs)
+		start = end
+	}
+
+	return res
+}
+
+// alignIndex moves index i of merged IPRanges rr, whose size is size, to
+// the nearest index of an IP address at the boundary of the CIDRs whose
+// prefix length is ones, or to size at the end.
+func (rr *IPRanges) alignIndex(i, size *big.Int, ones int) *big.Int {
+	if i.Sign() == 0 || i.Cmp(size) >= 0 {
+		return i
+	}
+
+	ip := nthIP(rr, i)
+	bits := ip.bitLen()
+	if ones >= bits {
+		return i
+	}
+
+	down := ip.num.and(lowBits(bits - ones).not())
+	lo := rr.rank(xIP{down, ip.version()})
+	hi := new(big.Int).Set(size)
+	if up := down.add(lowBits(bits - ones)); up.cmp(lowBits(bits)) < 0 {
+		hi = rr.rank(xIP{up.addOne(), ip.version()})
+	}
+
+	if new(big.Int).Sub(i, lo).Cmp(new(big.Int).Sub(hi, i)) <= 0 {
+		return lo
+	}
+
+	return hi
+}
+
+// rank returns the number of the IP addresses of merged IPRanges rr that
+// are less than xIP w.
+func (rr *IPRanges) rank(w xIP) *big.Int {
+	n := new(big.Int)
+	for _, r := range rr.ranges {
+		if r.start.cmp(w) >= 0 {
+			break
+		}
+		if r.end.cmp(w) < 0 {
+			n.Add(n, r.size())
+			continue
+		}
+		n.Add(n, w.num.sub(r.start.num).big())
+	}
+
+	return n
+}
@@ -0,0 +1,188 @@
+package iprange
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// partitionStrings converts the IPRanges of a partition as strings.
+func partitionStrings(parts []*IPRanges) []string {
+	res := make([]string, 0, len(parts))
+	for _, p := range parts {
+		res = append(res, p.String())
+	}
+
+	return res
+}
+
+func TestIPRangesPartition(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		ranges *IPRanges
+		k      int
+		want   []string
+	}{
+		{
+			name:   "even",
+			ranges: mustParse("172.18.0.0/30"),
+			k:      2,
+			want:   []string{"172.18.0.0/31", "172.18.0.2/31"},
+		},
+		{
+			name:   "uneven",
+			ranges: mustParse("172.18.0.1-172.18.0.5"),
+			k:      3,
+			want:   []string{"172.18.0.1", "172.18.0.2/31", "172.18.0.4/31"},
+		},
+		{
+			name:   "across ranges",
+			ranges: mustParse("172.18.0.1-172.18.0.3", "172.18.0.10-172.18.0.12"),
+			k:      2,
+			want:   []string{"172.18.0.1-172.18.0.3", "172.18.0.10-172.18.0.12"},
+		},
+		{
+			name:   "overlapping",
+			ranges: mustParse("172.18.0.3-172.18.0.4", "172.18.0.1-172.18.0.3"),
+			k:      2,
+			want:   []string{"172.18.0.1-172.18.0.2", "172.18.0.3-172.18.0.4"},
+		},
+		{
+			name:   "more pieces than IP addresses",
+			ranges: mustParse("172.18.0.1-172.18.0.2"),
+			k:      3,
+			want:   []string{"[]", "172.18.0.1", "172.18.0.2"},
+		},
+		{
+			name:   "IPv6",
+			ranges: mustParse("::/0"),
+			k:      2,
+			want:   []string{"::/1", "8000::/1"},
+		},
+		{
+			name:   "zero",
+			ranges: mustParse(),
+			k:      2,
+			want:   []string{"[]", "[]"},
+		},
+		{
+			name:   "no pieces",
+			ranges: mustParse("172.18.0.1"),
+			k:      0,
+			want:   []string{},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := partitionStrings(test.ranges.Partition(test.k))
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("IPRanges(%v).Partition(%d) = %v, want %v", test.ranges, test.k, got, test.want)
+			}
+		})
+	}
+}
+
+func TestIPRangesPartitionWeighted(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		ranges  *IPRanges
+		weights []int
+		want    []string
+	}{
+		{
+			name:    "weighted",
+			ranges:  mustParse("172.18.0.0/30"),
+			weights: []int{1, 3},
+			want:    []string{"172.18.0.0", "172.18.0.1-172.18.0.3"},
+		},
+		{
+			name:    "non-positive",
+			ranges:  mustParse("172.18.0.0/30"),
+			weights: []int{1, 0, -1, 1},
+			want:    []string{"172.18.0.0/31", "[]", "[]", "172.18.0.2/31"},
+		},
+		{
+			name:    "all zero",
+			ranges:  mustParse("172.18.0.0/30"),
+			weights: []int{0, 0},
+			want:    []string{"[]", "[]"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := partitionStrings(test.ranges.PartitionWeighted(test.weights))
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("IPRanges(%v).PartitionWeighted(%v) = %v, want %v", test.ranges, test.weights, got, test.want)
+			}
+		})
+	}
+}
+
+func TestIPRangesPartitionAligned(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		ranges *IPRanges
+		k      int
+		ones   int
+		want   []string
+	}{
+		{
+			name:   "aligned",
+			ranges: mustParse("172.18.0.0/22"),
+			k:      2,
+			ones:   24,
+			want:   []string{"172.18.0.0/23", "172.18.2.0/23"},
+		},
+		{
+			name:   "rounded",
+			ranges: mustParse("172.18.0.0/22"),
+			k:      3,
+			ones:   24,
+			want:   []string{"172.18.0.0/24", "172.18.1.0-172.18.2.255", "172.18.3.0/24"},
+		},
+		{
+			name:   "unaligned ranges",
+			ranges: mustParse("172.18.0.128-172.18.2.127"),
+			k:      2,
+			ones:   24,
+			want:   []string{"172.18.0.128/25", "172.18.1.0-172.18.2.127"},
+		},
+		{
+			name:   "too coarse",
+			ranges: mustParse("172.18.0.0/24"),
+			k:      2,
+			ones:   16,
+			want:   []string{"[]", "172.18.0.0/24"},
+		},
+		{
+			name:   "full length",
+			ranges: mustParse("172.18.0.1-172.18.0.4"),
+			k:      2,
+			ones:   32,
+			want:   []string{"172.18.0.1-172.18.0.2", "172.18.0.3-172.18.0.4"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := partitionStrings(test.ranges.PartitionAligned(test.k, test.ones))
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("IPRanges(%v).PartitionAligned(%d, %d) = %v, want %v", test.ranges, test.k, test.ones, got, test.want)
+			}
+		})
+	}
+}
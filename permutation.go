@@ -0,0 +1,181 @@
+package iprange
+
+import (
+	"math/big"
+	"net"
+	"sort"
+)
+
+// feistelRounds is the number of rounds of the Feistel network, which is
+// enough to scatter adjacent indexes all over the domain.
+const feistelRounds = 6
+
+type randomIPIterator struct {
+	// ranges are merged, so that every IP address is scanned exactly once.
+	ranges []ipRange
+	// offsets holds the index of the start of each IP range among all the
+	// IP addresses.
+	offsets []uint128
+	// last is the index of the last IP address.
+	last uint128
+	perm feistel
+	// pos is the index of the next position in the permutation, done is
+	// true if the randomIPIterator has been exhausted.
+	pos  uint128
+	done bool
+}
+
+// RandomIPIterator generates a new iterator for scanning each IP address of
+// IPRanges rr exactly once, in a pseudo-random order decided by seed, like
+// zmap does. The same seed always results in the same order. The order is
+// a permutation of the indexes of the IP addresses, computed on the fly by
+// a Feistel network with cycle-walking, so even the huge IPv6 ranges are
+// never materialized.
+func (rr *IPRanges) RandomIPIterator(seed int64) *randomIPIterator {
+	ri := &randomIPIterator{
+		ranges: rr.Merge().ranges,
+	}
+
+	var size uint128
+	for _, r := range ri.ranges {
+		ri.offsets = append(ri.offsets, size)
+		size = size.add(r.end.num.sub(r.start.num)).addOne()
+	}
+	ri.last = size.subOne()
+	ri.perm = newFeistel(ri.last.bitLen(), uint64(seed))
+	ri.Reset()
+
+	return ri
+}
+
+// Next returns the next IP address. If the randomIPIterator has been
+// exhausted, return nil.
+func (ri *randomIPIterator) Next() net.IP {
+	return ri.NextN(bigInt[1])
+}
+
+// NextN returns the next nth IP address, skipping the n-1 ones before it in
+// the permutation. If the randomIPIterator has been exhausted, return nil.
+// If n <= 0, it is equivalent to NextN(1).
+func (ri *randomIPIterator) NextN(n *big.Int) net.IP {
+	if ri.done {
+		return nil
+	}
+
+	if n.Sign() <= 0 {
+		n = bigInt[1]
+	}
+
+	// skip is n-1, which fits in uint128 even for n = 2^128.
+	skip, ok := uint128FromBig(new(big.Int).Sub(n, bigInt[1]))
+	if !ok {
+		ri.done = true
+		return nil
+	}
+
+	// The free positions are pos, ..., last, i.e. last-pos+1 of them.
+	if skip.cmp(ri.last.sub(ri.pos)) > 0 {
+		ri.done = true
+		return nil
+	}
+	i := ri.pos.add(skip)
+	if i == ri.last {
+		ri.done = true
+	} else {
+		ri.pos = i.addOne()
+	}
+
+	return ri.nth(ri.permute(i)).toIP()
+}
+
+// Reset resets random IP iterator, which scans in the same order again.
+func (ri *randomIPIterator) Reset() {
+	ri.pos = uint128{}
+	ri.done = len(ri.ranges) == 0
+}
+
+// permute returns the index that uint128 i is permuted to. The Feistel
+// network permutes a domain of a power of 2 which is at least the number of
+// the IP addresses but less than 4 times of it, so that cycle-walking, i.e.
+// permuting again until the index is in range, takes less than 4 rounds on
+// average.
+func (ri *randomIPIterator) permute(i uint128) uint128 {
+	for {
+		i = ri.perm.permute(i)
+		if i.cmp(ri.last) <= 0 {
+			return i
+		}
+	}
+}
+
+// nth returns the xIP at index i.
+func (ri *randomIPIterator) nth(i uint128) xIP {
+	k := sort.Search(len(ri.offsets), func(k int) bool {
+		return ri.offsets[k].cmp(i) > 0
+	}) - 1
+
+	return ri.ranges[k].start.add(i.sub(ri.offsets[k]))
+}
+
+// feistel is a balanced Feistel network, which is a permutation of the
+// integers of 2*half bits.
+type feistel struct {
+	half int
+	keys [feistelRounds]uint64
+}
+
+// newFeistel returns a feistel whose domain covers the integers of n bits,
+// with the round keys derived from seed.
+func newFeistel(n int, seed uint64) feistel {
+	f := feistel{
+		half: (n + 1) / 2,
+	}
+	for i := range f.keys {
+		seed += 0x9e3779b97f4a7c15
+		f.keys[i] = mix64(seed)
+	}
+
+	return f
+}
+
+// permute returns the integer that uint128 x is permuted to.
+func (f feistel) permute(x uint128) uint128 {
+	if f.half == 0 {
+		return x
+	}
+
+	mask := ^uint64(0) >> (64 - f.half)
+	l, r := f.split(x)
+	for _, k := range f.keys {
+		l, r = r, l^(mix64(r^k)&mask)
+	}
+
+	return f.join(l, r)
+}
+
+// split splits uint128 x into its high and low halves.
+func (f feistel) split(x uint128) (l, r uint64) {
+	if f.half == 64 {
+		return x.hi, x.lo
+	}
+
+	mask := ^uint64(0) >> (64 - f.half)
+	return (x.lo>>f.half | x.hi<<(64-f.half)) & mask, x.lo & mask
+}
+
+// join joins the high and low halves l and r as uint128.
+func (f feistel) join(l, r uint64) uint128 {
+	if f.half == 64 {
+		return uint128{l, r}
+	}
+
+	return uint128{l >> (64 - f.half), l<<f.half | r}
+}
+
+// mix64 is the finalizer of SplitMix64, which scrambles the bits of x.
+func mix64(x uint64) uint64 {
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+
+	return x ^ x>>31
+}
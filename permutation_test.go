@@ -0,0 +1,206 @@
+package iprange
+
+import (
+	"math/big"
+	"net"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIPRangesRandomIPIterator(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		ranges []string
+	}{
+		{
+			name:   "single",
+			ranges: []string{"172.18.0.1"},
+		},
+		{
+			name:   "two",
+			ranges: []string{"172.18.0.1-172.18.0.2"},
+		},
+		{
+			name:   "odd",
+			ranges: []string{"172.18.0.1-172.18.0.5", "172.18.0.9"},
+		},
+		{
+			name:   "CIDR",
+			ranges: []string{"172.18.0.0/24"},
+		},
+		{
+			name:   "overlapping",
+			ranges: []string{"172.18.0.200-172.18.1.10", "172.18.0.0/24", "10.0.0.1-10.0.0.100"},
+		},
+		{
+			name:   "IPv6",
+			ranges: []string{"fd00::ff00-fd00::1:10", "fd00::a"},
+		},
+		{
+			name:   "zero",
+			ranges: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := mustParse(test.ranges...)
+			var want []string
+			iter := rr.Merge().IPIterator()
+			for ip := iter.Next(); ip != nil; ip = iter.Next() {
+				want = append(want, ip.String())
+			}
+
+			var got []string
+			ri := rr.RandomIPIterator(1)
+			for ip := ri.Next(); ip != nil; ip = ri.Next() {
+				got = append(got, ip.String())
+			}
+			if ri.Next() != nil {
+				t.Errorf("IPRanges(%v).RandomIPIterator(1).Next() = non-nil after exhausted", rr)
+			}
+
+			ri.Reset()
+			var again []string
+			for ip := ri.Next(); ip != nil; ip = ri.Next() {
+				again = append(again, ip.String())
+			}
+			if !cmp.Equal(again, got) {
+				t.Errorf("IPRanges(%v).RandomIPIterator(1) scans %v after Reset, want %v", rr, again, got)
+			}
+
+			sort.Slice(got, func(i, j int) bool {
+				return ipToXIP(net.ParseIP(got[i])).cmp(ipToXIP(net.ParseIP(got[j]))) < 0
+			})
+			if !cmp.Equal(got, want) {
+				t.Errorf("IPRanges(%v).RandomIPIterator(1) scans %v, want %v", rr, got, want)
+			}
+		})
+	}
+}
+
+func TestIPRangesRandomIPIteratorSeed(t *testing.T) {
+	t.Parallel()
+
+	rr := mustParse("172.18.0.0/16")
+	scan := func(seed int64) []string {
+		var ips []string
+		ri := rr.RandomIPIterator(seed)
+		for i := 0; i < 16; i++ {
+			ips = append(ips, ri.Next().String())
+		}
+		return ips
+	}
+
+	a, b, c := scan(1), scan(1), scan(2)
+	if !cmp.Equal(a, b) {
+		t.Errorf("IPRanges(%v).RandomIPIterator(1) scans %v and %v", rr, a, b)
+	}
+	if cmp.Equal(a, c) {
+		t.Errorf("IPRanges(%v).RandomIPIterator(2) scans %v, want different from seed 1", rr, c)
+	}
+
+	// A random order hardly scans in ascending order.
+	if sort.SliceIsSorted(a, func(i, j int) bool {
+		return ipToXIP(net.ParseIP(a[i])).cmp(ipToXIP(net.ParseIP(a[j]))) < 0
+	}) {
+		t.Errorf("IPRanges(%v).RandomIPIterator(1) scans %v in ascending order", rr, a)
+	}
+}
+
+func TestIPRangesRandomIPIteratorNextN(t *testing.T) {
+	t.Parallel()
+
+	rr := mustParse("172.18.0.1-172.18.0.100", "172.18.1.0/26")
+	var all []net.IP
+	ri := rr.RandomIPIterator(42)
+	for ip := ri.Next(); ip != nil; ip = ri.Next() {
+		all = append(all, ip)
+	}
+
+	tests := []struct {
+		name string
+		n    []int64
+		want []net.IP
+	}{
+		{
+			name: "one by one",
+			n:    []int64{1, 1, 1},
+			want: all[:3],
+		},
+		{
+			name: "skip",
+			n:    []int64{3, 10, 0},
+			want: []net.IP{all[2], all[12], all[13]},
+		},
+		{
+			name: "to the last",
+			n:    []int64{int64(len(all)), 1},
+			want: []net.IP{all[len(all)-1], nil},
+		},
+		{
+			name: "beyond",
+			n:    []int64{int64(len(all)) + 1, 1},
+			want: []net.IP{nil, nil},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ri := rr.RandomIPIterator(42)
+			var got []net.IP
+			for _, n := range test.n {
+				got = append(got, ri.NextN(big.NewInt(n)))
+			}
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("IPRanges(%v).RandomIPIterator(42).NextN(%v) = %v, want %v", rr, test.n, got, test.want)
+			}
+		})
+	}
+}
+
+func TestIPRangesRandomIPIteratorHuge(t *testing.T) {
+	t.Parallel()
+
+	rr := mustParse("::/0")
+	ri := rr.RandomIPIterator(7)
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		ip := ri.Next()
+		if ip == nil || seen[ip.String()] {
+			t.Fatalf("IPRanges(%v).RandomIPIterator(7).Next() = %v, want a new IP address", rr, ip)
+		}
+		seen[ip.String()] = true
+	}
+
+	half := new(big.Int).Lsh(big.NewInt(1), 127)
+	if ip := ri.NextN(half); ip == nil {
+		t.Errorf("IPRanges(%v).RandomIPIterator(7).NextN(%v) = nil, want non-nil", rr, half)
+	}
+	if ip := ri.NextN(half); ip != nil {
+		t.Errorf("IPRanges(%v).RandomIPIterator(7).NextN(%v) = %v, want nil", rr, half, ip)
+	}
+}
+
+func TestIPRangesRandomIPIteratorNextNWholeSpace(t *testing.T) {
+	t.Parallel()
+
+	// The 2^128th IP address of ::/0 is the last one of the permutation.
+	rr := mustParse("::/0")
+	n := new(big.Int).Lsh(big.NewInt(1), 128)
+	if ip := rr.RandomIPIterator(7).NextN(n); ip == nil {
+		t.Errorf("IPRanges(%v).RandomIPIterator(7).NextN(%v) = nil, want non-nil", rr, n)
+	}
+	if ip := rr.RandomIPIterator(7).NextN(n.Add(n, big.NewInt(1))); ip != nil {
+		t.Errorf("IPRanges(%v).RandomIPIterator(7).NextN(%v) = %v, want nil", rr, n, ip)
+	}
+}
@@ -0,0 +1,208 @@
+package iprange
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+)
+
+// Pool is a named pool of IP addresses in a PoolSet.
+type Pool struct {
+	// Name identifies the pool in its PoolSet.
+	Name string
+
+	// Ranges are the IP addresses of the pool.
+	Ranges *IPRanges
+
+	// Priority decides the order in which the pools of a PoolSet are
+	// allocated from, the lower the earlier. The pools of the same
+	// Priority keep the order they are given in.
+	Priority int
+
+	// Labels are selected by PoolSet.AllocateSelector.
+	Labels map[string]string
+}
+
+// PoolUtilization is the utilization of a pool in a PoolSet.
+type PoolUtilization struct {
+	Name string
+	Size *big.Int
+	Used *big.Int
+}
+
+// PoolSet is a set of named pools which do not overlap with each other,
+// such as "primary", "overflow" and "reserved-for-gateways". IP addresses
+// are allocated from the pools in the order of their priorities, falling
+// back to the next pool when one is exhausted, and optionally only from
+// the pools with some labels.
+//
+// A PoolSet is safe for concurrent use by multiple goroutines.
+type PoolSet struct {
+	pools  []poolEntry
+	byName map[string]*Allocator
+}
+
+// poolEntry is a Pool along with its Allocator.
+type poolEntry struct {
+	Pool
+	alloc *Allocator
+}
+
+// NewPoolSet returns a PoolSet of pools. The pools may be of different IP
+// versions, but no two of them may share an IP address, in which case the
+// error errPoolOverlap will be returned. The names of the pools must be
+// unique, otherwise the error errDuplicatePool will be returned, and each
+// pool must have its Ranges, otherwise the error errEmptyPool will be
+// returned. The Labels of the pools are copied.
+func NewPoolSet(pools ...Pool) (*PoolSet, error) {
+	ps := &PoolSet{
+		pools:  make([]poolEntry, 0, len(pools)),
+		byName: make(map[string]*Allocator, len(pools)),
+	}
+	for _, p := range pools {
+		if _, ok := ps.byName[p.Name]; ok {
+			return nil, fmt.Errorf("%w: %q", errDuplicatePool, p.Name)
+		}
+		if p.Ranges == nil {
+			return nil, fmt.Errorf("%w: %q", errEmptyPool, p.Name)
+		}
+		if p.Labels != nil {
+			labels := make(map[string]string, len(p.Labels))
+			for k, v := range p.Labels {
+				labels[k] = v
+			}
+			p.Labels = labels
+		}
+
+		a := NewAllocator(p.Ranges)
+		ps.pools = append(ps.pools, poolEntry{p, a})
+		ps.byName[p.Name] = a
+	}
+
+	if err := ps.checkOverlap(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(ps.pools, func(i, j int) bool {
+		return ps.pools[i].Priority < ps.pools[j].Priority
+	})
+
+	return ps, nil
+}
+
+// checkOverlap returns the error errPoolOverlap if any two pools share an
+// IP address. Like IPRanges.IsOverlap, it sorts the IP ranges of all the
+// pools, so that only adjacent ones need to be compared.
+func (ps *PoolSet) checkOverlap() error {
+	type tagged struct {
+		ipRange
+		pool int
+	}
+
+	var rs []tagged
+	for i, p := range ps.pools {
+		for _, r := range p.alloc.pool {
+			rs = append(rs, tagged{r, i})
+		}
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		vi, vj := rs[i].start.version(), rs[j].start.version()
+		if vi != vj {
+			return vi < vj
+		}
+		return rs[i].start.cmp(rs[j].start) < 0
+	})
+
+	// last is the one that ends last among the IP ranges before rs[i].
+	for i, last := 1, 0; i < len(rs); i++ {
+		if rs[i].start.version() == rs[last].start.version() && rs[i].start.cmp(rs[last].end) <= 0 {
+			return fmt.Errorf("%w: %q and %q", errPoolOverlap, ps.pools[rs[last].pool].Name, ps.pools[rs[i].pool].Name)
+		}
+		if rs[i].start.version() != rs[last].start.version() || rs[i].end.cmp(rs[last].end) > 0 {
+			last = i
+		}
+	}
+
+	return nil
+}
+
+// Allocator returns the Allocator of the pool named name, or nil if there
+// is no such pool. It can be used to allocate or release specific IP
+// addresses of the pool.
+func (ps *PoolSet) Allocator(name string) *Allocator {
+	return ps.byName[name]
+}
+
+// Allocate allocates an IP address from the pools in the order of their
+// priorities, and returns the name of the pool it is allocated from. The
+// error errPoolExhausted will be returned when all the pools are
+// exhausted.
+func (ps *PoolSet) Allocate() (name string, ip net.IP, err error) {
+	return ps.AllocateSelector(nil)
+}
+
+// AllocateSelector is like Allocate, but only allocates from the pools
+// whose labels contain all the labels of selector. The error
+// errPoolExhausted will be returned when all such pools are exhausted, or
+// there is no such pool at all.
+func (ps *PoolSet) AllocateSelector(selector map[string]string) (name string, ip net.IP, err error) {
+	for _, p := range ps.pools {
+		if !p.matches(selector) {
+			continue
+		}
+
+		ip, err := p.alloc.Allocate()
+		if IsPoolExhausted(err) {
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+
+		return p.Name, ip, nil
+	}
+
+	return "", nil, errPoolExhausted
+}
+
+// Release releases net.IP ip back to the pool it pertains to, and returns
+// the name of the pool. The error errNotInPool will be returned when ip
+// does not pertain to any pool, and errNotAllocated when ip has not been
+// allocated.
+func (ps *PoolSet) Release(ip net.IP) (name string, err error) {
+	w := ipToXIP(ip)
+	for _, p := range ps.pools {
+		if p.alloc.inPool(w) {
+			return p.Name, p.alloc.Release(ip)
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", errNotInPool, ip)
+}
+
+// Utilization returns the utilization of each pool, in the order of their
+// priorities.
+func (ps *PoolSet) Utilization() []PoolUtilization {
+	res := make([]PoolUtilization, 0, len(ps.pools))
+	for _, p := range ps.pools {
+		res = append(res, PoolUtilization{
+			Name: p.Name,
+			Size: rangesSize(p.alloc.pool),
+			Used: p.alloc.Used(),
+		})
+	}
+
+	return res
+}
+
+// matches reports whether the labels of poolEntry p contain all the labels
+// of selector.
+func (p *poolEntry) matches(selector map[string]string) bool {
+	for k, v := range selector {
+		if lv, ok := p.Labels[k]; !ok || lv != v {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,180 @@
+package iprange
+
+import (
+	"errors"
+	"math/big"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// mustParse parses IP range strings rs, and panics on error.
+func mustParse(rs ...string) *IPRanges {
+	ranges, err := Parse(rs...)
+	if err != nil {
+		panic(err)
+	}
+
+	return ranges
+}
+
+var newPoolSetTests = []struct {
+	name  string
+	pools []Pool
+	err   error
+}{
+	{
+		name: "disjoint",
+		pools: []Pool{
+			{Name: "a", Ranges: mustParse("172.18.0.0/24", "172.18.2.0/24")},
+			{Name: "b", Ranges: mustParse("172.18.1.0/24", "172.18.3.0/24")},
+		},
+	},
+	{
+		name: "dual-stack",
+		pools: []Pool{
+			{Name: "a", Ranges: mustParse("172.18.0.0/24")},
+			{Name: "b", Ranges: mustParse("::/0")},
+		},
+	},
+	{
+		name: "overlap",
+		pools: []Pool{
+			{Name: "a", Ranges: mustParse("172.18.0.0/24", "172.18.5.0/24")},
+			{Name: "b", Ranges: mustParse("172.18.1.0/24")},
+			{Name: "c", Ranges: mustParse("172.18.2.0-172.18.5.0")},
+		},
+		err: errPoolOverlap,
+	},
+	{
+		name: "overlap after long range",
+		pools: []Pool{
+			{Name: "a", Ranges: mustParse("172.18.0.0/16")},
+			{Name: "b", Ranges: mustParse("172.18.1.0/24", "172.18.9.0/24")},
+		},
+		err: errPoolOverlap,
+	},
+	{
+		name: "overlap IPv6",
+		pools: []Pool{
+			{Name: "a", Ranges: mustParse("172.18.0.0/24")},
+			{Name: "b", Ranges: mustParse("fd00::/64")},
+			{Name: "c", Ranges: mustParse("fd00::ffff:ffff:ffff:ffff-fd00:0:0:1::")},
+		},
+		err: errPoolOverlap,
+	},
+	{
+		name: "duplicate name",
+		pools: []Pool{
+			{Name: "a", Ranges: mustParse("172.18.0.0/24")},
+			{Name: "a", Ranges: mustParse("172.18.1.0/24")},
+		},
+		err: errDuplicatePool,
+	},
+	{
+		name: "nil ranges",
+		pools: []Pool{
+			{Name: "a", Ranges: mustParse("172.18.0.0/24")},
+			{Name: "b"},
+		},
+		err: errEmptyPool,
+	},
+}
+
+func TestNewPoolSet(t *testing.T) {
+	t.Parallel()
+	for _, test := range newPoolSetTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := NewPoolSet(test.pools...)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("NewPoolSet() err %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestNewPoolSetCopiesLabels(t *testing.T) {
+	t.Parallel()
+	labels := map[string]string{"role": "gateway"}
+	ps, err := NewPoolSet(Pool{Name: "gateways", Ranges: mustParse("172.18.0.1"), Labels: labels})
+	if err != nil {
+		t.Fatalf("NewPoolSet() err %q", err)
+	}
+
+	// Changing the labels afterwards does not affect the PoolSet.
+	labels["role"] = "node"
+	selector := map[string]string{"role": "gateway"}
+	if name, _, err := ps.AllocateSelector(selector); err != nil || name != "gateways" {
+		t.Fatalf("PoolSet.AllocateSelector(%v) = %v, %v, want gateways", selector, name, err)
+	}
+}
+
+func TestPoolSet(t *testing.T) {
+	t.Parallel()
+	ps, err := NewPoolSet(
+		Pool{Name: "overflow", Ranges: mustParse("172.18.1.0/31"), Priority: 1},
+		Pool{Name: "gateways", Ranges: mustParse("172.18.0.1"), Priority: 1, Labels: map[string]string{"role": "gateway"}},
+		Pool{Name: "primary", Ranges: mustParse("172.18.0.2-3")},
+	)
+	if err != nil {
+		t.Fatalf("NewPoolSet() err %q", err)
+	}
+
+	var got []string
+	for {
+		name, ip, err := ps.Allocate()
+		if err != nil {
+			if !errors.Is(err, errPoolExhausted) {
+				t.Fatalf("PoolSet.Allocate() err %q", err)
+			}
+			break
+		}
+		got = append(got, name+" "+ip.String())
+	}
+	want := []string{
+		"primary 172.18.0.2",
+		"primary 172.18.0.3",
+		"overflow 172.18.1.0",
+		"overflow 172.18.1.1",
+		"gateways 172.18.0.1",
+	}
+	if !cmp.Equal(got, want) {
+		t.Fatalf("PoolSet.Allocate() = %q, want %q", got, want)
+	}
+
+	if name, err := ps.Release(net.ParseIP("172.18.0.1")); err != nil || name != "gateways" {
+		t.Fatalf("PoolSet.Release(172.18.0.1) = %v, %v, want gateways", name, err)
+	}
+	if _, err := ps.Release(net.ParseIP("172.18.2.1")); !errors.Is(err, errNotInPool) {
+		t.Fatalf("PoolSet.Release(172.18.2.1) err %v, want %v", err, errNotInPool)
+	}
+	if _, err := ps.Release(net.ParseIP("172.18.0.1")); !errors.Is(err, errNotAllocated) {
+		t.Fatalf("PoolSet.Release(172.18.0.1) err %v, want %v", err, errNotAllocated)
+	}
+	if err := ps.Allocator("primary").Release(net.ParseIP("172.18.0.2")); err != nil {
+		t.Fatalf("PoolSet.Allocator(primary).Release(172.18.0.2) err %q", err)
+	}
+	if ps.Allocator("unknown") != nil {
+		t.Fatalf("PoolSet.Allocator(unknown) != nil")
+	}
+
+	name, ip, err := ps.AllocateSelector(map[string]string{"role": "gateway"})
+	if err != nil || name != "gateways" || ip.String() != "172.18.0.1" {
+		t.Fatalf("PoolSet.AllocateSelector(role=gateway) = %v, %v, %v, want gateways 172.18.0.1", name, ip, err)
+	}
+	if _, _, err := ps.AllocateSelector(map[string]string{"role": "gateway"}); !errors.Is(err, errPoolExhausted) {
+		t.Fatalf("PoolSet.AllocateSelector(role=gateway) err %v, want %v", err, errPoolExhausted)
+	}
+
+	wantUtil := []PoolUtilization{
+		{"primary", big.NewInt(2), big.NewInt(1)},
+		{"overflow", big.NewInt(2), big.NewInt(2)},
+		{"gateways", big.NewInt(1), big.NewInt(1)},
+	}
+	if util := ps.Utilization(); !cmp.Equal(util, wantUtil, cmp.Comparer(func(x, y *big.Int) bool { return x.Cmp(y) == 0 })) {
+		t.Fatalf("PoolSet.Utilization() = %v, want %v", util, wantUtil)
+	}
+}
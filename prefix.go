@@ -0,0 +1,269 @@
+package iprange
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"sync"
+)
+
+// PrefixAllocator allocates prefixes (i.e. subnets) from a pool defined by
+// IPRanges, such as /24 out of a /12, or /64 out of a /48. It is a buddy
+// allocator: a free prefix is split in halves until it fits the prefix
+// length requested, and a released prefix is coalesced with its free
+// buddy back into their parent prefix, which keeps fragmentation low.
+//
+// A PrefixAllocator is safe for concurrent use by multiple goroutines.
+type PrefixAllocator struct {
+	mu      sync.Mutex
+	version family
+	bits    int
+	// pool is never modified.
+	pool []ipRange
+	// free holds the starting IP addresses of the free prefixes, in
+	// ascending order, indexed by their prefix lengths.
+	free      [][]uint128
+	allocated map[block]struct{}
+}
+
+// block is a prefix whose IP address is a uint128.
+type block struct {
+	start uint128
+	ones  int
+}
+
+// NewPrefixAllocator returns a PrefixAllocator whose pool is IPRanges pool,
+// with all of its prefixes free.
+func NewPrefixAllocator(pool *IPRanges) *PrefixAllocator {
+	rs := pool.Merge()
+	pa := &PrefixAllocator{
+		version:   rs.version,
+		bits:      32,
+		pool:      rs.ranges,
+		allocated: make(map[block]struct{}),
+	}
+	if pa.version == IPv6 {
+		pa.bits = 128
+	}
+	pa.free = make([][]uint128, pa.bits+1)
+
+	iter := newCIDRIterator(rs.ranges)
+	for {
+		ip, ones, ok := iter.next()
+		if !ok {
+			break
+		}
+		pa.free[ones] = append(pa.free[ones], ip.num)
+	}
+
+	return pa
+}
+
+// AllocatePrefix allocates a free prefix whose prefix length is bits. The
+// smallest free prefix that fits is split, and the lowest one is preferred
+// among them. The error errPoolExhausted will be returned when there is no
+// free prefix large enough.
+func (pa *PrefixAllocator) AllocatePrefix(bits int) (netip.Prefix, error) {
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+
+	if bits < 0 || bits > pa.bits {
+		return netip.Prefix{}, fmt.Errorf("%w: /%d", errInvalidIPRangeFormat, bits)
+	}
+
+	ones := bits
+	for ones >= 0 && len(pa.free[ones]) == 0 {
+		ones--
+	}
+	if ones < 0 {
+		return netip.Prefix{}, errPoolExhausted
+	}
+
+	start := pa.free[ones][0]
+	pa.free[ones] = pa.free[ones][1:]
+	pa.split(block{start, ones}, bits, start)
+
+	return pa.prefix(block{start, bits}), nil
+}
+
+// AllocateSpecificPrefix allocates netip.Prefix p, which is masked first.
+// The error errNotInPool will be returned when p does not pertain to the
+// pool, and errAllocated when p overlaps any allocated prefix.
+func (pa *PrefixAllocator) AllocateSpecificPrefix(p netip.Prefix) error {
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+
+	b, err := pa.block(p)
+	if err != nil {
+		return err
+	}
+
+	for ones := b.ones; ones >= 0; ones-- {
+		start := b.start.and(pa.mask(ones))
+		i, ok := pa.search(ones, start)
+		if !ok {
+			continue
+		}
+
+		pa.free[ones] = append(pa.free[ones][:i], pa.free[ones][i+1:]...)
+		pa.split(block{start, ones}, b.ones, b.start)
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", errAllocated, p)
+}
+
+// ReleasePrefix releases netip.Prefix p, which is masked first, back to
+// the pool. The error errNotInPool will be returned when p does not
+// pertain to the pool, and errNotAllocated when p has not been allocated.
+func (pa *PrefixAllocator) ReleasePrefix(p netip.Prefix) error {
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+
+	b, err := pa.block(p)
+	if err != nil {
+		return err
+	}
+	if _, ok := pa.allocated[b]; !ok {
+		return fmt.Errorf("%w: %s", errNotAllocated, p)
+	}
+	delete(pa.allocated, b)
+
+	// Coalesce with the buddy as long as it is free, and their parent
+	// pertains to the pool.
+	for b.ones > 0 {
+		parent := block{b.start.and(pa.mask(b.ones - 1)), b.ones - 1}
+		if !pa.inPool(parent) {
+			break
+		}
+
+		half := lowBits(pa.bits - b.ones).addOne()
+		buddy := parent.start
+		if buddy == b.start {
+			buddy = buddy.add(half)
+		}
+		i, ok := pa.search(b.ones, buddy)
+		if !ok {
+			break
+		}
+
+		pa.free[b.ones] = append(pa.free[b.ones][:i], pa.free[b.ones][i+1:]...)
+		b = parent
+	}
+	pa.insert(b)
+
+	return nil
+}
+
+// LargestFreePrefix returns the shortest prefix length, i.e. the largest
+// prefix, that can still be allocated. It returns -1 if the pool is
+// exhausted.
+func (pa *PrefixAllocator) LargestFreePrefix() int {
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+
+	for ones, starts := range pa.free {
+		if len(starts) != 0 {
+			return ones
+		}
+	}
+
+	return -1
+}
+
+// Allocated returns the allocated prefixes, in ascending order.
+func (pa *PrefixAllocator) Allocated() []netip.Prefix {
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+
+	bs := make([]block, 0, len(pa.allocated))
+	for b := range pa.allocated {
+		bs = append(bs, b)
+	}
+	sort.Slice(bs, func(i, j int) bool {
+		return bs[i].start.cmp(bs[j].start) < 0
+	})
+
+	prefixes := make([]netip.Prefix, 0, len(bs))
+	for _, b := range bs {
+		prefixes = append(prefixes, pa.prefix(b))
+	}
+
+	return prefixes
+}
+
+// split allocates the block of prefix length ones containing uint128 at
+// out of free block b, while the other halves split off are freed.
+func (pa *PrefixAllocator) split(b block, ones int, at uint128) {
+	for b.ones < ones {
+		b.ones++
+		half := lowBits(pa.bits - b.ones).addOne()
+		if at.cmp(b.start.add(half)) >= 0 {
+			pa.insert(block{b.start, b.ones})
+			b.start = b.start.add(half)
+		} else {
+			pa.insert(block{b.start.add(half), b.ones})
+		}
+	}
+	pa.allocated[b] = struct{}{}
+}
+
+// block converts netip.Prefix p as block. The error errNotInPool will be
+// returned when p does not pertain to the pool.
+func (pa *PrefixAllocator) block(p netip.Prefix) (block, error) {
+	r, err := prefixToRange(p)
+	if err != nil {
+		return block{}, err
+	}
+
+	b := block{
+		start: r.start.num,
+		ones:  pa.bits - r.end.num.sub(r.start.num).bitLen(),
+	}
+	if r.start.version() != pa.version || !pa.inPool(b) {
+		return block{}, fmt.Errorf("%w: %s", errNotInPool, p)
+	}
+
+	return b, nil
+}
+
+// inPool reports whether block b pertains to the pool.
+func (pa *PrefixAllocator) inPool(b block) bool {
+	start := xIP{b.start, pa.version}
+	end := xIP{b.start.or(lowBits(pa.bits - b.ones)), pa.version}
+	i := searchRanges(pa.pool, func(r ipRange) bool {
+		return r.end.cmp(start) >= 0
+	})
+
+	return i < len(pa.pool) && pa.pool[i].start.cmp(start) <= 0 && pa.pool[i].end.cmp(end) >= 0
+}
+
+// search returns the index of uint128 start among the free blocks of
+// prefix length ones, ok is false if it is not free.
+func (pa *PrefixAllocator) search(ones int, start uint128) (i int, ok bool) {
+	starts := pa.free[ones]
+	i = sort.Search(len(starts), func(i int) bool {
+		return starts[i].cmp(start) >= 0
+	})
+
+	return i, i < len(starts) && starts[i] == start
+}
+
+// insert frees block b.
+func (pa *PrefixAllocator) insert(b block) {
+	i, _ := pa.search(b.ones, b.start)
+	starts := append(pa.free[b.ones], uint128{})
+	copy(starts[i+1:], starts[i:])
+	starts[i] = b.start
+	pa.free[b.ones] = starts
+}
+
+// mask returns the network mask of prefix length ones.
+func (pa *PrefixAllocator) mask(ones int) uint128 {
+	return lowBits(pa.bits - ones).not()
+}
+
+// prefix converts block b as netip.Prefix.
+func (pa *PrefixAllocator) prefix(b block) netip.Prefix {
+	return netip.PrefixFrom(xIP{b.start, pa.version}.toAddr(), b.ones)
+}
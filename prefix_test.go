@@ -0,0 +1,191 @@
+package iprange
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var prefixAllocatorTests = []struct {
+	name  string
+	pool  []string
+	steps []string
+	want  []string
+	free  int
+	err   error
+}{
+	{
+		name:  "allocate",
+		pool:  []string{"10.0.0.0/22"},
+		steps: []string{"+24", "+26", "+24", "+25"},
+		want:  []string{"10.0.0.0/24", "10.0.1.0/26", "10.0.1.128/25", "10.0.2.0/24"},
+		free:  24,
+	},
+	{
+		name:  "best fit",
+		pool:  []string{"10.0.0.0/24", "10.0.2.0/26"},
+		steps: []string{"+26", "+25"},
+		want:  []string{"10.0.0.0/25", "10.0.2.0/26"},
+		free:  25,
+	},
+	{
+		name:  "specific",
+		pool:  []string{"10.0.0.0/16"},
+		steps: []string{"10.0.128.0/24", "10.0.0.0/17", "+17"},
+		err:   errPoolExhausted,
+	},
+	{
+		name:  "coalesce",
+		pool:  []string{"fd00::/48"},
+		steps: []string{"+64", "+64", "+63", "-fd00:0:0:1::/64", "-fd00::/64", "-fd00:0:0:2::/63"},
+		want:  []string{},
+		free:  48,
+	},
+	{
+		name:  "coalesce within pool",
+		pool:  []string{"10.0.1.0-10.0.2.255"},
+		steps: []string{"+24", "-10.0.1.0/24"},
+		want:  []string{},
+		free:  24,
+	},
+	{
+		name:  "release",
+		pool:  []string{"10.0.0.0/24"},
+		steps: []string{"+26", "+26", "-10.0.0.0/26", "+25"},
+		want:  []string{"10.0.0.64/26", "10.0.0.128/25"},
+		free:  26,
+	},
+	{
+		name:  "exhausted",
+		pool:  []string{"10.0.0.0/24"},
+		steps: []string{"+25", "+25", "+32"},
+		err:   errPoolExhausted,
+	},
+	{
+		name:  "overlap",
+		pool:  []string{"10.0.0.0/24"},
+		steps: []string{"10.0.0.64/26", "10.0.0.0/25"},
+		err:   errAllocated,
+	},
+	{
+		name:  "not in pool",
+		pool:  []string{"10.0.0.0/24"},
+		steps: []string{"10.0.0.0/23"},
+		err:   errNotInPool,
+	},
+	{
+		name:  "diff version",
+		pool:  []string{"10.0.0.0/24"},
+		steps: []string{"fd00::/64"},
+		err:   errNotInPool,
+	},
+	{
+		name:  "not allocated",
+		pool:  []string{"10.0.0.0/24"},
+		steps: []string{"+26", "-10.0.0.0/25"},
+		err:   errNotAllocated,
+	},
+	{
+		name:  "bad prefix length",
+		pool:  []string{"10.0.0.0/24"},
+		steps: []string{"+33"},
+		err:   errInvalidIPRangeFormat,
+	},
+}
+
+func TestPrefixAllocator(t *testing.T) {
+	t.Parallel()
+	for _, test := range prefixAllocatorTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			pool, err := Parse(test.pool...)
+			if err != nil {
+				t.Fatalf("Parse(%q) err %q", test.pool, err)
+			}
+
+			// A step is either "+bits" for AllocatePrefix, "-prefix" for
+			// ReleasePrefix, or "prefix" for AllocateSpecificPrefix.
+			pa := NewPrefixAllocator(pool)
+			for _, step := range test.steps {
+				switch step[0] {
+				case '+':
+					var bits int
+					for _, c := range step[1:] {
+						bits = bits*10 + int(c-'0')
+					}
+					_, err = pa.AllocatePrefix(bits)
+				case '-':
+					err = pa.ReleasePrefix(netip.MustParsePrefix(step[1:]))
+				default:
+					err = pa.AllocateSpecificPrefix(netip.MustParsePrefix(step))
+				}
+				if err != nil {
+					break
+				}
+			}
+			if err != nil || test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("PrefixAllocator(%v) err %v, want %v", pool, err, test.err)
+				}
+				return
+			}
+
+			prefixes := []string{}
+			for _, p := range pa.Allocated() {
+				prefixes = append(prefixes, p.String())
+			}
+			if !cmp.Equal(prefixes, test.want) {
+				t.Fatalf("PrefixAllocator(%v).Allocated() = %v, want %v", pool, prefixes, test.want)
+			}
+			if free := pa.LargestFreePrefix(); free != test.free {
+				t.Fatalf("PrefixAllocator(%v).LargestFreePrefix() = %v, want %v", pool, free, test.free)
+			}
+		})
+	}
+}
+
+func TestPrefixAllocatorExhaust(t *testing.T) {
+	t.Parallel()
+	pool, err := Parse("172.18.0.0/22")
+	if err != nil {
+		t.Fatalf("Parse() err %q", err)
+	}
+
+	// Allocating every IP address as /32 and releasing them all leaves the
+	// pool as it was.
+	pa := NewPrefixAllocator(pool)
+	var prefixes []netip.Prefix
+	for {
+		p, err := pa.AllocatePrefix(32)
+		if err != nil {
+			if !errors.Is(err, errPoolExhausted) {
+				t.Fatalf("PrefixAllocator(%v).AllocatePrefix(32) err %q", pool, err)
+			}
+			break
+		}
+		prefixes = append(prefixes, p)
+	}
+	if len(prefixes) != 1024 || pa.LargestFreePrefix() != -1 {
+		t.Fatalf("PrefixAllocator(%v) allocated %d /32, want 1024", pool, len(prefixes))
+	}
+
+	for i := len(prefixes) - 1; i >= 0; i -= 2 {
+		if err := pa.ReleasePrefix(prefixes[i]); err != nil {
+			t.Fatalf("PrefixAllocator(%v).ReleasePrefix(%v) err %q", pool, prefixes[i], err)
+		}
+	}
+	if free := pa.LargestFreePrefix(); free != 32 {
+		t.Fatalf("PrefixAllocator(%v).LargestFreePrefix() = %v, want 32", pool, free)
+	}
+	for i := 0; i < len(prefixes); i += 2 {
+		if err := pa.ReleasePrefix(prefixes[i]); err != nil {
+			t.Fatalf("PrefixAllocator(%v).ReleasePrefix(%v) err %q", pool, prefixes[i], err)
+		}
+	}
+	if free := pa.LargestFreePrefix(); free != 22 {
+		t.Fatalf("PrefixAllocator(%v).LargestFreePrefix() = %v, want 22", pool, free)
+	}
+}
@@ -0,0 +1,268 @@
+package iprange
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"sync"
+)
+
+// QuotaAllocator allocates IP addresses and prefixes of a shared pool to
+// owners, such as tenants, each of which may hold a limited number of
+// allocations. An allocation is either an IP address or a prefix, and
+// both come from the same PrefixAllocator, an IP address being a prefix of
+// the full length.
+//
+// A QuotaAllocator is safe for concurrent use by multiple goroutines.
+type QuotaAllocator struct {
+	mu sync.Mutex
+	pa *PrefixAllocator
+
+	defaultQuota int
+	quotas       map[string]int
+
+	// owned holds the allocations of each owner, and allocs holds all the
+	// allocations in ascending order, which never overlap.
+	owned  map[string]map[block]struct{}
+	allocs []ownedRange
+}
+
+// ownedRange is an allocation along with its owner.
+type ownedRange struct {
+	ipRange
+	owner string
+}
+
+// NewQuotaAllocator returns a QuotaAllocator whose pool is IPRanges pool,
+// where each owner may hold at most defaultQuota allocations unless its
+// quota is set by SetQuota. A negative quota means no limit.
+func NewQuotaAllocator(pool *IPRanges, defaultQuota int) *QuotaAllocator {
+	return &QuotaAllocator{
+		pa:           NewPrefixAllocator(pool),
+		defaultQuota: defaultQuota,
+		quotas:       make(map[string]int),
+		owned:        make(map[string]map[block]struct{}),
+	}
+}
+
+// SetQuota sets the maximum number of allocations owner may hold, which
+// does not affect the allocations it already holds. A negative quota means
+// no limit.
+func (q *QuotaAllocator) SetQuota(owner string, quota int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.quotas[owner] = quota
+}
+
+// Quota returns the maximum number of allocations owner may hold, and the
+// number of allocations it holds.
+func (q *QuotaAllocator) Quota(owner string) (quota, used int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.quota(owner), len(q.owned[owner])
+}
+
+// Allocate allocates an IP address for owner. A *QuotaExceededError will
+// be returned when owner holds as many allocations as its quota allows,
+// and the error errPoolExhausted when there is no free IP address.
+func (q *QuotaAllocator) Allocate(owner string) (net.IP, error) {
+	p, err := q.AllocatePrefix(owner, q.pa.bits)
+	if err != nil {
+		return nil, err
+	}
+
+	return net.IP(p.Addr().AsSlice()), nil
+}
+
+// AllocateSpecific allocates net.IP ip for owner, see Allocate and
+// PrefixAllocator.AllocateSpecificPrefix.
+func (q *QuotaAllocator) AllocateSpecific(owner string, ip net.IP) error {
+	p, err := q.ipPrefix(ip)
+	if err != nil {
+		return err
+	}
+
+	return q.AllocateSpecificPrefix(owner, p)
+}
+
+// AllocatePrefix allocates a prefix whose prefix length is bits for owner,
+// see Allocate and PrefixAllocator.AllocatePrefix.
+func (q *QuotaAllocator) AllocatePrefix(owner string, bits int) (netip.Prefix, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.checkQuota(owner); err != nil {
+		return netip.Prefix{}, err
+	}
+
+	p, err := q.pa.AllocatePrefix(bits)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	q.add(owner, p)
+
+	return p, nil
+}
+
+// AllocateSpecificPrefix allocates netip.Prefix p for owner, see Allocate
+// and PrefixAllocator.AllocateSpecificPrefix.
+func (q *QuotaAllocator) AllocateSpecificPrefix(owner string, p netip.Prefix) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.checkQuota(owner); err != nil {
+		return err
+	}
+
+	if err := q.pa.AllocateSpecificPrefix(p); err != nil {
+		return err
+	}
+	q.add(owner, p)
+
+	return nil
+}
+
+// Release releases net.IP ip, which must have been allocated as an IP
+// address rather than within a prefix. The error errNotAllocated will be
+// returned when ip has not been allocated.
+func (q *QuotaAllocator) Release(ip net.IP) error {
+	p, err := q.ipPrefix(ip)
+	if err != nil {
+		return err
+	}
+
+	return q.ReleasePrefix(p)
+}
+
+// ReleasePrefix releases netip.Prefix p, see
+// PrefixAllocator.ReleasePrefix.
+func (q *QuotaAllocator) ReleasePrefix(p netip.Prefix) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.pa.ReleasePrefix(p); err != nil {
+		return err
+	}
+	q.remove(p)
+
+	return nil
+}
+
+// Owned returns the IP addresses that owner holds, including the ones of
+// the prefixes it holds, as IPRanges, which is merged.
+func (q *QuotaAllocator) Owned(owner string) *IPRanges {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ranges := make([]ipRange, 0, len(q.owned[owner]))
+	for b := range q.owned[owner] {
+		ranges = append(ranges, q.blockRange(b))
+	}
+
+	return &IPRanges{
+		version: q.pa.version,
+		ranges:  mergeRanges(ranges),
+		merged:  true,
+	}
+}
+
+// Owner returns the owner of net.IP ip, which is allocated either as an IP
+// address or within a prefix. ok is false if ip has not been allocated.
+func (q *QuotaAllocator) Owner(ip net.IP) (owner string, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	w := ipToXIP(ip)
+	if w.version() != q.pa.version {
+		return "", false
+	}
+
+	i := sort.Search(len(q.allocs), func(i int) bool {
+		return q.allocs[i].end.cmp(w) >= 0
+	})
+	if i == len(q.allocs) || q.allocs[i].start.cmp(w) > 0 {
+		return "", false
+	}
+
+	return q.allocs[i].owner, true
+}
+
+// quota returns the quota of owner.
+func (q *QuotaAllocator) quota(owner string) int {
+	if quota, ok := q.quotas[owner]; ok {
+		return quota
+	}
+
+	return q.defaultQuota
+}
+
+// checkQuota returns a *QuotaExceededError if owner holds as many
+// allocations as its quota allows.
+func (q *QuotaAllocator) checkQuota(owner string) error {
+	if quota := q.quota(owner); quota >= 0 && len(q.owned[owner]) >= quota {
+		return &QuotaExceededError{
+			Owner: owner,
+			Quota: quota,
+		}
+	}
+
+	return nil
+}
+
+// add records allocated netip.Prefix p for owner.
+func (q *QuotaAllocator) add(owner string, p netip.Prefix) {
+	b, _ := q.pa.block(p)
+	if q.owned[owner] == nil {
+		q.owned[owner] = make(map[block]struct{})
+	}
+	q.owned[owner][b] = struct{}{}
+
+	r := q.blockRange(b)
+	i := q.search(r.start)
+	q.allocs = append(q.allocs, ownedRange{})
+	copy(q.allocs[i+1:], q.allocs[i:])
+	q.allocs[i] = ownedRange{r, owner}
+}
+
+// remove forgets released netip.Prefix p.
+func (q *QuotaAllocator) remove(p netip.Prefix) {
+	b, _ := q.pa.block(p)
+	i := q.search(xIP{b.start, q.pa.version})
+	owner := q.allocs[i].owner
+	q.allocs = append(q.allocs[:i], q.allocs[i+1:]...)
+
+	delete(q.owned[owner], b)
+	if len(q.owned[owner]) == 0 {
+		delete(q.owned, owner)
+	}
+}
+
+// search returns the index of the first allocation that does not start
+// before xIP start.
+func (q *QuotaAllocator) search(start xIP) int {
+	return sort.Search(len(q.allocs), func(i int) bool {
+		return q.allocs[i].start.cmp(start) >= 0
+	})
+}
+
+// blockRange converts block b as ipRange.
+func (q *QuotaAllocator) blockRange(b block) ipRange {
+	return ipRange{
+		start: xIP{b.start, q.pa.version},
+		end:   xIP{b.start.or(lowBits(q.pa.bits - b.ones)), q.pa.version},
+	}
+}
+
+// ipPrefix converts net.IP ip as the netip.Prefix of the full length.
+func (q *QuotaAllocator) ipPrefix(ip net.IP) (netip.Prefix, error) {
+	w := ipToXIP(ip)
+	if w.version() == Unknown {
+		return netip.Prefix{}, fmt.Errorf("%w: %s", errInvalidIPRangeFormat, ip)
+	}
+	addr := w.toAddr()
+
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
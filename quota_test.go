@@ -0,0 +1,97 @@
+package iprange
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestQuotaAllocator(t *testing.T) {
+	t.Parallel()
+	pool, err := Parse("10.0.0.0/24")
+	if err != nil {
+		t.Fatalf("Parse() err %q", err)
+	}
+
+	q := NewQuotaAllocator(pool, 2)
+	q.SetQuota("c", -1)
+
+	ip1, err := q.Allocate("a")
+	if err != nil {
+		t.Fatalf("QuotaAllocator.Allocate(a) err %q", err)
+	}
+	p1, err := q.AllocatePrefix("a", 26)
+	if err != nil {
+		t.Fatalf("QuotaAllocator.AllocatePrefix(a, 26) err %q", err)
+	}
+	if ip1.String() != "10.0.0.0" || p1.String() != "10.0.0.64/26" {
+		t.Fatalf("QuotaAllocator.Allocate(a) = %v and %v, want 10.0.0.0 and 10.0.0.64/26", ip1, p1)
+	}
+
+	_, err = q.Allocate("a")
+	var qerr *QuotaExceededError
+	if !errors.As(err, &qerr) || qerr.Owner != "a" || qerr.Quota != 2 || !IsQuotaExceeded(err) {
+		t.Fatalf("QuotaAllocator.Allocate(a) err %v, want QuotaExceededError", err)
+	}
+
+	if err := q.AllocateSpecific("b", net.ParseIP("10.0.0.200")); err != nil {
+		t.Fatalf("QuotaAllocator.AllocateSpecific(b, 10.0.0.200) err %q", err)
+	}
+	if err := q.AllocateSpecificPrefix("b", netip.MustParsePrefix("10.0.0.64/27")); !errors.Is(err, errAllocated) {
+		t.Fatalf("QuotaAllocator.AllocateSpecificPrefix(b, 10.0.0.64/27) err %v, want %v", err, errAllocated)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := q.Allocate("c"); err != nil {
+			t.Fatalf("QuotaAllocator.Allocate(c) err %q", err)
+		}
+	}
+
+	if owned := q.Owned("a").String(); owned != "[10.0.0.0 10.0.0.64/26]" {
+		t.Fatalf("QuotaAllocator.Owned(a) = %v, want [10.0.0.0 10.0.0.64/26]", owned)
+	}
+	if owned := q.Owned("c").String(); owned != "[10.0.0.1-10.0.0.3 10.0.0.201-10.0.0.202]" {
+		t.Fatalf("QuotaAllocator.Owned(c) = %v, want [10.0.0.1-10.0.0.3 10.0.0.201-10.0.0.202]", owned)
+	}
+	if owned := q.Owned("d").String(); owned != "[]" {
+		t.Fatalf("QuotaAllocator.Owned(d) = %v, want []", owned)
+	}
+	if quota, used := q.Quota("c"); quota != -1 || used != 5 {
+		t.Fatalf("QuotaAllocator.Quota(c) = %v, %v, want -1, 5", quota, used)
+	}
+
+	owners := map[string]string{
+		"10.0.0.0":   "a",
+		"10.0.0.100": "a",
+		"10.0.0.200": "b",
+		"10.0.0.3":   "c",
+		"10.0.0.202": "c",
+		"10.0.0.6":   "",
+		"fd00::1":    "",
+	}
+	for ip, want := range owners {
+		if owner, ok := q.Owner(net.ParseIP(ip)); owner != want || ok != (want != "") {
+			t.Fatalf("QuotaAllocator.Owner(%v) = %v, %v, want %v", ip, owner, ok, want)
+		}
+	}
+
+	// Releasing frees up the quota.
+	if err := q.Release(net.ParseIP("10.0.0.70")); !errors.Is(err, errNotAllocated) {
+		t.Fatalf("QuotaAllocator.Release(10.0.0.70) err %v, want %v", err, errNotAllocated)
+	}
+	if err := q.ReleasePrefix(p1); err != nil {
+		t.Fatalf("QuotaAllocator.ReleasePrefix(%v) err %q", p1, err)
+	}
+	if _, ok := q.Owner(net.ParseIP("10.0.0.100")); ok {
+		t.Fatalf("QuotaAllocator.Owner(10.0.0.100) ok after ReleasePrefix")
+	}
+	if _, err := q.Allocate("a"); err != nil {
+		t.Fatalf("QuotaAllocator.Allocate(a) err %q", err)
+	}
+	if err := q.Release(ip1); err != nil {
+		t.Fatalf("QuotaAllocator.Release(%v) err %q", ip1, err)
+	}
+	if quota, used := q.Quota("a"); quota != 2 || used != 1 {
+		t.Fatalf("QuotaAllocator.Quota(a) = %v, %v, want 2, 1", quota, used)
+	}
+}
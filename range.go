@@ -1,9 +1,10 @@
 package iprange
 
 import (
-	"fmt"
 	"math/big"
 	"net"
+	"net/netip"
+	"strconv"
 	"strings"
 )
 
@@ -14,21 +15,44 @@ type ipRange struct {
 	end   xIP
 }
 
-// parse parses the IP range format string as ipRange that records the
-// starting and ending IP addresses. The error errInvalidIPRangeFormat
-// wiil be returned when r is invalid.
-func parse(r string) (*ipRange, error) {
+// parse parses the IP range format string as ipRanges. Most IP range
+// formats result in a single ipRange, while the IPv4 octet notation and
+// non-contiguous wildcard masks may result in many, see parseOctets and
+// parseMask. A *ParseError wrapping the error errInvalidIPRangeFormat will
+// be returned when r is invalid.
+func parse(r string) ([]ipRange, *ParseError) {
+	if addr, mask, wildcard, ok := isMaskNotation(r); ok {
+		return parseMask(r, addr, mask, wildcard)
+	}
+	if isOctetNotation(r) {
+		return parseOctets(r)
+	}
+
+	v, err := parseRange(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return []ipRange{*v}, nil
+}
+
+// parseRange parses the IP range format string as ipRange that records the
+// starting and ending IP addresses. A *ParseError wrapping the error
+// errInvalidIPRangeFormat will be returned when r is invalid.
+func parseRange(r string) (*ipRange, *ParseError) {
 	if r == "" {
-		return nil, fmt.Errorf(`%w: ""`, errInvalidIPRangeFormat)
+		return nil, newParseError(r, ReasonEmpty)
 	}
 
-	fmtErr := fmt.Errorf("%w: %s", errInvalidIPRangeFormat, r)
 	// 172.18.0.0/24
 	// fd00::/64
-	if strings.Contains(r, "/") {
+	if before, _, found := strings.Cut(r, "/"); found {
 		ip, ipNet, err := net.ParseCIDR(r)
 		if err != nil {
-			return nil, fmtErr
+			if net.ParseIP(before) == nil {
+				return nil, newParseError(r, ReasonBadIP)
+			}
+			return nil, newParseError(r, ReasonBadPrefixLength)
 		}
 
 		n := len(ipNet.IP)
@@ -38,8 +62,8 @@ func parse(r string) (*ipRange, error) {
 		}
 
 		return &ipRange{
-			start: xIP{normalizeIP(ip)},
-			end:   xIP{normalizeIP(lastIP)},
+			start: ipToXIP(ip),
+			end:   ipToXIP(lastIP),
 		}, nil
 	}
 
@@ -47,7 +71,7 @@ func parse(r string) (*ipRange, error) {
 	if found {
 		startIP := net.ParseIP(before)
 		if startIP == nil {
-			return nil, fmtErr
+			return nil, newParseError(r, ReasonBadStartIP)
 		}
 
 		endIP := net.ParseIP(after)
@@ -61,27 +85,19 @@ func parse(r string) (*ipRange, error) {
 			after = before[:index+1] + after
 			endIP = net.ParseIP(after)
 			if endIP == nil {
-				return nil, fmtErr
-			}
-
-			start := xIP{normalizeIP(startIP)}
-			end := xIP{normalizeIP(endIP)}
-			if end.cmp(start) < 0 {
-				return nil, fmtErr
+				return nil, newParseError(r, ReasonBadEndIP)
 			}
-
-			return &ipRange{
-				start: start,
-				end:   end,
-			}, nil
 		}
 
 		// 172.18.0.1-172.18.1.10
 		// fd00::1-fd00::1:a
-		start := xIP{normalizeIP(startIP)}
-		end := xIP{normalizeIP(endIP)}
+		start := ipToXIP(startIP)
+		end := ipToXIP(endIP)
+		if start.version() != end.version() {
+			return nil, newParseError(r, ReasonFamilyMismatch)
+		}
 		if end.cmp(start) < 0 {
-			return nil, fmtErr
+			return nil, newParseError(r, ReasonReversedRange)
 		}
 
 		return &ipRange{
@@ -94,19 +110,18 @@ func parse(r string) (*ipRange, error) {
 	// fd00::1
 	ip := net.ParseIP(r)
 	if ip == nil {
-		return nil, fmtErr
+		return nil, newParseError(r, ReasonBadIP)
 	}
-	nIP := normalizeIP(ip)
+	w := ipToXIP(ip)
 
 	return &ipRange{
-		start: xIP{nIP},
-		end:   xIP{nIP},
+		start: w,
+		end:   w,
 	}, nil
 }
 
-// contains reports whether ipRange r contains net.IP ip.
-func (r *ipRange) contains(ip net.IP) bool {
-	w := xIP{ip}
+// contains reports whether ipRange r contains xIP w.
+func (r *ipRange) contains(w xIP) bool {
 	switch r.start.cmp(w) {
 	case 0:
 		return true
@@ -119,42 +134,111 @@ func (r *ipRange) contains(ip net.IP) bool {
 
 // equal reports whether ipRange r is equal to r2.
 func (r *ipRange) equal(r2 *ipRange) bool {
-	return r.start.Equal(r2.start.IP) && r.end.Equal(r2.end.IP)
+	return r.start == r2.start && r.end == r2.end
 }
 
 // size calculates the total number of IP addresses that pertain to ipRange r.
 func (r *ipRange) size() *big.Int {
-	n := big.NewInt(1)
-	n.Add(n, ipToInt(r.end.IP))
+	n := r.end.num.sub(r.start.num).big()
 
-	return n.Sub(n, ipToInt(r.start.IP))
+	return n.Add(n, bigInt[1])
 }
 
 // String implements fmt.Stringer.
 func (r *ipRange) String() string {
-	inc := r.size()
-	dv := new(big.Int).Sub(inc, bigInt[1])
-	bl := dv.BitLen()
-	if bl == 0 {
+	dv := r.end.num.sub(r.start.num)
+	if dv.isZero() {
 		return r.start.String()
 	}
 
-	if inc.And(inc, dv).Sign() == 0 {
-		bits := 32
-		if r.start.version() == IPv6 {
-			bits = 128
-		}
+	// The size of r is a power of 2, and r.start is aligned to it.
+	if dv.and(dv.addOne()).isZero() && r.start.num.and(dv).isZero() {
+		return r.start.String() + "/" + strconv.Itoa(r.start.bitLen()-dv.bitLen())
+	}
 
-		ip := r.start.IP
-		mask := net.CIDRMask(bits-bl, bits)
-		if ip.Mask(mask).Equal(ip) {
-			ipNet := net.IPNet{
-				IP:   ip,
-				Mask: mask,
-			}
-			return ipNet.String()
+	return r.start.String() + "-" + r.end.String()
+}
+
+// Range is a single IP range of an IPRanges, from its starting IP address
+// to its ending IP address, both inclusive. The zero value of Range is not
+// a valid IP range, whose version is Unknown.
+type Range ipRange
+
+// Ranges returns all the Range of IPRanges rr, in their original order.
+func (rr *IPRanges) Ranges() []Range {
+	rs := make([]Range, 0, len(rr.ranges))
+	for _, r := range rr.ranges {
+		rs = append(rs, Range(r))
+	}
+
+	return rs
+}
+
+// Ranges returns all the Range of DualStackRanges ds, IPv4 ones first.
+func (ds *DualStackRanges) Ranges() []Range {
+	return append(ds.IPv4().Ranges(), ds.IPv6().Ranges()...)
+}
+
+// Version returns the IP version of Range r.
+func (r Range) Version() family {
+	return r.start.version()
+}
+
+// Start returns the starting IP address of Range r.
+func (r Range) Start() net.IP {
+	return r.start.toIP()
+}
+
+// End returns the ending IP address of Range r.
+func (r Range) End() net.IP {
+	return r.end.toIP()
+}
+
+// StartAddr returns the starting IP address of Range r as netip.Addr.
+func (r Range) StartAddr() netip.Addr {
+	return r.start.toAddr()
+}
+
+// EndAddr returns the ending IP address of Range r as netip.Addr.
+func (r Range) EndAddr() netip.Addr {
+	return r.end.toAddr()
+}
+
+// Size calculates the total number of IP addresses that pertain to Range r.
+func (r Range) Size() *big.Int {
+	return (*ipRange)(&r).size()
+}
+
+// Contains reports whether Range r contains net.IP ip.
+func (r Range) Contains(ip net.IP) bool {
+	w := ipToXIP(ip)
+	if w.version() != r.start.version() {
+		return false
+	}
+
+	return (*ipRange)(&r).contains(w)
+}
+
+// Prefixes returns the minimal set of CIDR that exactly covers Range r.
+func (r Range) Prefixes() []*net.IPNet {
+	if r.start.version() == Unknown {
+		return nil
+	}
+
+	var ipNets []*net.IPNet
+	iter := newCIDRIterator([]ipRange{ipRange(r)})
+	for {
+		ipNet := iter.Next()
+		if ipNet == nil {
+			break
 		}
+		ipNets = append(ipNets, ipNet)
 	}
 
-	return r.start.String() + "-" + r.end.String()
+	return ipNets
+}
+
+// String implements fmt.Stringer.
+func (r Range) String() string {
+	return (*ipRange)(&r).String()
 }
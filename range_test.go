@@ -0,0 +1,134 @@
+package iprange
+
+import (
+	"math/big"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIPRangesRanges(t *testing.T) {
+	t.Parallel()
+	ranges, err := Parse("172.18.0.10-20", "172.18.0.0/24")
+	if err != nil {
+		t.Fatalf("Parse() err %q", err)
+	}
+
+	rs := ranges.Ranges()
+	if len(rs) != 2 {
+		t.Fatalf("len(IPRanges(%v).Ranges()) = %d, want 2", ranges, len(rs))
+	}
+
+	r := rs[0]
+	if !r.Start().Equal(net.IPv4(172, 18, 0, 10)) || len(r.Start()) != net.IPv4len {
+		t.Fatalf("Range(%v).Start() = %v, want 172.18.0.10", r, r.Start())
+	}
+	if !r.End().Equal(net.IPv4(172, 18, 0, 20)) {
+		t.Fatalf("Range(%v).End() = %v, want 172.18.0.20", r, r.End())
+	}
+	if r.StartAddr() != netip.MustParseAddr("172.18.0.10") {
+		t.Fatalf("Range(%v).StartAddr() = %v, want 172.18.0.10", r, r.StartAddr())
+	}
+	if r.EndAddr() != netip.MustParseAddr("172.18.0.20") {
+		t.Fatalf("Range(%v).EndAddr() = %v, want 172.18.0.20", r, r.EndAddr())
+	}
+	if r.Version() != IPv4 {
+		t.Fatalf("Range(%v).Version() = %v, want IPv4", r, r.Version())
+	}
+	if r.Size().Cmp(big.NewInt(11)) != 0 {
+		t.Fatalf("Range(%v).Size() = %v, want 11", r, r.Size())
+	}
+	if rs[1].String() != "172.18.0.0/24" {
+		t.Fatalf("Range(%v).String() = %v, want 172.18.0.0/24", rs[1], rs[1].String())
+	}
+
+	dual, err := ParseDualStack("fd00::1", "172.18.0.1")
+	if err != nil {
+		t.Fatalf("ParseDualStack() err %q", err)
+	}
+	var ss []string
+	for _, r := range dual.Ranges() {
+		ss = append(ss, r.String())
+	}
+	if want := []string{"172.18.0.1", "fd00::1"}; !cmp.Equal(ss, want) {
+		t.Fatalf("DualStackRanges(%v).Ranges() = %q, want %q", dual, ss, want)
+	}
+}
+
+var rangeContainsTests = []struct {
+	name string
+	r    string
+	ip   net.IP
+	want bool
+}{
+	{"IPv4 contain", "172.18.0.1-10", net.IPv4(172, 18, 0, 10), true},
+	{"IPv4 not contain", "172.18.0.1-10", net.IPv4(172, 18, 0, 11), false},
+	{"IPv6 contain", "fd00::/64", net.ParseIP("fd00::1"), true},
+	{"diff version", "172.18.0.1-10", net.ParseIP("fd00::1"), false},
+	{"invalid IP", "172.18.0.1-10", nil, false},
+}
+
+func TestRangeContains(t *testing.T) {
+	t.Parallel()
+	for _, test := range rangeContainsTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ranges, err := Parse(test.r)
+			if err != nil {
+				t.Fatalf("Parse(%q) err %q", test.r, err)
+			}
+			r := ranges.Ranges()[0]
+			if contains := r.Contains(test.ip); contains != test.want {
+				t.Fatalf("Range(%v).Contains(%v) = %v, want %v", r, test.ip, contains, test.want)
+			}
+		})
+	}
+}
+
+var rangePrefixesTests = []struct {
+	name string
+	r    string
+	want []*net.IPNet
+}{
+	{
+		name: "IPv4",
+		r:    "172.18.0.1-4",
+		want: []*net.IPNet{
+			{IP: net.IPv4(172, 18, 0, 1).To4(), Mask: net.CIDRMask(32, 32)},
+			{IP: net.IPv4(172, 18, 0, 2).To4(), Mask: net.CIDRMask(31, 32)},
+			{IP: net.IPv4(172, 18, 0, 4).To4(), Mask: net.CIDRMask(32, 32)},
+		},
+	},
+	{
+		name: "IPv6",
+		r:    "fd00::/64",
+		want: []*net.IPNet{
+			{IP: net.ParseIP("fd00::"), Mask: net.CIDRMask(64, 128)},
+		},
+	},
+}
+
+func TestRangePrefixes(t *testing.T) {
+	t.Parallel()
+	for _, test := range rangePrefixesTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ranges, err := Parse(test.r)
+			if err != nil {
+				t.Fatalf("Parse(%q) err %q", test.r, err)
+			}
+			r := ranges.Ranges()[0]
+			if prefixes := r.Prefixes(); !cmp.Equal(prefixes, test.want) {
+				t.Fatalf("Range(%v).Prefixes() = %v, want %v", r, prefixes, test.want)
+			}
+		})
+	}
+
+	if prefixes := (Range{}).Prefixes(); prefixes != nil {
+		t.Fatalf("Range{}.Prefixes() = %v, want nil", prefixes)
+	}
+}
@@ -1,6 +1,7 @@
 package iprange
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 	"net"
@@ -41,12 +42,24 @@ func (f family) String() string {
 //	172.18.0.0/24           fd00::/64
 //	172.18.0.1-10           fd00::1-a
 //	172.18.0.1-172.18.1.10  fd00::1-fd00::1:a
+//	10.0.*.1, 10.0.1-3.1-254, 192.168.0,2,4.0/24 (IPv4 octet notation)
+//	10.0.0.0/255.255.255.0, 10.0.0.0 0.0.0.255 (netmasks and wildcard masks)
 //
 // Dual-stack IP ranges are not allowed, The IP version of an IPRanges
 // can only be IPv4, IPv6, or unknown (zero value).
+//
+// An IPRanges is never modified once created: all of its methods return
+// new IPRanges instead, so it is safe to share an IPRanges between
+// goroutines. The only exceptions are UnmarshalText and UnmarshalJSON,
+// which decode into an IPRanges that is not shared yet.
 type IPRanges struct {
 	version family
-	ranges  []ipRange
+	// ranges may be shared between IPRanges, and must not be modified.
+	ranges []ipRange
+
+	// merged records whether ranges are known to be ordered and
+	// deduplicated, which enables binary search in Contains.
+	merged bool
 }
 
 // Parse parses a set of IP range format strings as IPRanges, the slice
@@ -56,31 +69,64 @@ type IPRanges struct {
 // The error errInvalidIPRangeFormat wiil be returned when one of IP range
 // string is invalid. And dual-stack IP ranges are not allowed, the error
 // errDualStackIPRanges occurs when parsing a set of IP range strings, where
-// there are both IPv4 and IPv6 addresses.
+// there are both IPv4 and IPv6 addresses. Either error is wrapped in a
+// *ParseError, which tells the IP range string that fails to parse.
+//
+// The IP address before a prefix length or a netmask is kept as the start,
+// so 10.0.0.5/24 and 10.0.0.5/255.255.255.0 are 10.0.0.5-10.0.0.255, while
+// the bits of the IP address under a wildcard mask are ignored as ACLs do,
+// so 10.0.0.5 0.0.0.255 is 10.0.0.0/24.
 func Parse(rs ...string) (*IPRanges, error) {
+	return parseRanges(rs, false)
+}
+
+// ParseAll is like Parse, but it does not stop at the first IP range
+// string that fails to parse. Instead, all the *ParseError are collected
+// and joined together by errors.Join. The IP version of IPRanges is decided
+// by the first valid IP range string.
+func ParseAll(rs ...string) (*IPRanges, error) {
+	return parseRanges(rs, true)
+}
+
+// parseRanges parses a set of IP range format strings as IPRanges. If all
+// is false, it returns on the first error.
+func parseRanges(rs []string, all bool) (*IPRanges, error) {
 	if len(rs) == 0 {
 		return &IPRanges{}, nil
 	}
 
 	version := Unknown
 	ranges := make([]ipRange, 0, len(rs))
+	var errs []error
 	for i, r := range rs {
-		v, err := parse(r)
-		if err != nil {
-			return nil, err
-		}
+		vs, err := parse(r)
+		if err == nil {
+			if version == Unknown {
+				version = vs[0].start.version()
+			}
 
-		if i == 0 {
-			version = IPv4
-			if v.start.To4() == nil {
-				version = IPv6
+			if vs[0].start.version() != version {
+				err = &ParseError{
+					Input:  r,
+					Reason: ReasonFamilyMismatch,
+					err:    errDualStackIPRanges,
+				}
 			}
 		}
 
-		if v.start.version() != version {
-			return nil, errDualStackIPRanges
+		if err != nil {
+			err.Index = i
+			if !all {
+				return nil, err
+			}
+			errs = append(errs, err)
+			continue
 		}
-		ranges = append(ranges, *v)
+		ranges = append(ranges, vs...)
+	}
+
+	if len(errs) != 0 {
+		return nil, errors.Join(errs...)
 	}
 
 	return &IPRanges{
@@ -103,14 +149,25 @@ func (rr *IPRanges) Version() family {
 
 // Contains reports whether IPRanges rr contain net.IP ip. If rr is IPv4
 // and ip is IPv6, then it is also considered not contained, and vice versa.
+//
+// Contains scans rr linearly, unless rr is the result of Merge, Union, Diff
+// or Intersect, in which case it takes O(log n) time without allocations.
 func (rr *IPRanges) Contains(ip net.IP) bool {
-	w := xIP{ip}
+	return rr.contains(ipToXIP(ip))
+}
+
+// contains reports whether IPRanges rr contain xIP w.
+func (rr *IPRanges) contains(w xIP) bool {
 	if w.version() != rr.version {
 		return false
 	}
 
+	if rr.merged {
+		return rr.search(w)
+	}
+
 	for _, r := range rr.ranges {
-		if r.contains(ip) {
+		if r.contains(w) {
 			return true
 		}
 	}
@@ -118,6 +175,40 @@ func (rr *IPRanges) Contains(ip net.IP) bool {
 	return false
 }
 
+// search reports whether merged IPRanges rr contain xIP w by binary search.
+func (rr *IPRanges) search(w xIP) bool {
+	// Find the first ipRange that starts after w, w can only pertain to
+	// the one before it.
+	i, j := 0, len(rr.ranges)
+	for i < j {
+		h := int(uint(i+j) >> 1)
+		if rr.ranges[h].start.cmp(w) <= 0 {
+			i = h + 1
+		} else {
+			j = h
+		}
+	}
+
+	return i > 0 && rr.ranges[i-1].end.cmp(w) >= 0
+}
+
+// Filter returns the net.IP in ips that IPRanges rr contain, in their
+// original order. rr is merged first if needed, so that each net.IP is
+// looked up in O(log n) time.
+func (rr *IPRanges) Filter(ips []net.IP) []net.IP {
+	rs := rr.Merge()
+
+	var res []net.IP
+	for _, ip := range ips {
+		w := ipToXIP(ip)
+		if w.version() == rs.version && rs.search(w) {
+			res = append(res, ip)
+		}
+	}
+
+	return res
+}
+
 // MergeEqual reports whether IPRanges rr is equal to rr2, but both rr and
 // rr2 are pre-merged, which means they are both ordered and deduplicated.
 func (rr *IPRanges) MergeEqual(rr2 *IPRanges) bool {
@@ -125,10 +216,7 @@ func (rr *IPRanges) MergeEqual(rr2 *IPRanges) bool {
 		return false
 	}
 
-	rr = rr.DeepCopy().Merge()
-	rr2 = rr2.DeepCopy().Merge()
-
-	return rr.Equal(rr2)
+	return rr.Merge().Equal(rr2.Merge())
 }
 
 // Equal reports whether IPRanges rr is equal to rr2.
@@ -154,62 +242,36 @@ func (rr *IPRanges) Equal(rr2 *IPRanges) bool {
 // Size calculates the total number of IP addresses that pertain to
 // IPRanges rr.
 func (rr *IPRanges) Size() *big.Int {
-	n := big.NewInt(0)
-	for _, r := range rr.ranges {
-		n.Add(n, r.size())
-	}
-
-	return n
+	return rangesSize(rr.ranges)
 }
 
 // Merge merges the duplicate parts of multiple ipRanges in rr and sort
-// them by their respective starting xIP.
+// them by their respective starting xIP. The result is a new IPRanges, rr
+// itself is left untouched.
 func (rr *IPRanges) Merge() *IPRanges {
-	if len(rr.ranges) <= 1 {
-		return rr
-	}
-
-	sort.Slice(rr.ranges, func(i, j int) bool {
-		return rr.ranges[i].start.cmp(rr.ranges[j].start) < 0
-	})
-
-	cur := -1
-	merged := make([]ipRange, 0, len(rr.ranges))
-	for _, r := range rr.ranges {
-		if cur == -1 {
-			merged = append(merged, r)
-			cur++
-			continue
-		}
-
-		if merged[cur].end.next().cmp(r.start) == 0 {
-			merged[cur].end = r.end
-			continue
-		}
-
-		if merged[cur].end.cmp(r.start) < 0 {
-			merged = append(merged, r)
-			cur++
-			continue
-		}
-
-		if merged[cur].end.cmp(r.end) < 0 {
-			merged[cur].end = r.end
+	if rr.merged || len(rr.ranges) <= 1 {
+		return &IPRanges{
+			version: rr.version,
+			ranges:  rr.ranges,
+			merged:  true,
 		}
 	}
-	rr.ranges = merged
 
-	return rr
+	return &IPRanges{
+		version: rr.version,
+		ranges:  mergeRanges(append([]ipRange(nil), rr.ranges...)),
+		merged:  true,
+	}
 }
 
 // IsOverlap reports whether IPRanges rr have overlapping parts.
 func (rr *IPRanges) IsOverlap() bool {
 	n := len(rr.ranges)
-	if n <= 1 {
+	if n <= 1 || rr.merged {
 		return false
 	}
 
-	rs := rr.DeepCopy().ranges
+	rs := append([]ipRange(nil), rr.ranges...)
 	sort.Slice(rs, func(i, j int) bool {
 		return rs[i].start.cmp(rs[j].start) < 0
 	})
@@ -232,9 +294,16 @@ func (rr *IPRanges) Union(rs *IPRanges) *IPRanges {
 	if rr.version != rs.version {
 		return rr.Merge()
 	}
-	rr.ranges = append(rr.ranges, rs.ranges...)
 
-	return rr.Merge()
+	ranges := make([]ipRange, 0, len(rr.ranges)+len(rs.ranges))
+	ranges = append(ranges, rr.ranges...)
+	ranges = append(ranges, rs.ranges...)
+
+	return &IPRanges{
+		version: rr.version,
+		ranges:  mergeRanges(ranges),
+		merged:  true,
+	}
 }
 
 // Diff calculates the difference of IPRanges rr and rs with the same IP
@@ -251,13 +320,85 @@ func (rr *IPRanges) Diff(rs *IPRanges) *IPRanges {
 		return rr.Merge()
 	}
 
-	rs = rs.DeepCopy()
-	omr := rr.Merge().ranges
-	tmr := rs.Merge().ranges
+	return &IPRanges{
+		version: rr.version,
+		ranges:  diffRanges(rr.Merge().ranges, rs.Merge().ranges),
+		merged:  true,
+	}
+}
+
+// Intersect calculates the intersection of IPRanges rr and rs with the
+// same IP version. The result is always merged (ordered and deduplicated).
+//
+//	Input:  [172.18.0.20-30, 172.18.0.1-25] ∩ [172.18.0.5-25]
+//	Output: [172.18.0.5-25]
+func (rr *IPRanges) Intersect(rs *IPRanges) *IPRanges {
+	if rr.version != rs.version {
+		return &IPRanges{
+			version: rr.version,
+			merged:  true,
+		}
+	}
+
+	if len(rr.ranges) == 0 || len(rs.ranges) == 0 {
+		return &IPRanges{
+			version: rr.version,
+			merged:  true,
+		}
+	}
+
+	return &IPRanges{
+		version: rr.version,
+		ranges:  intersectRanges(rr.Merge().ranges, rs.Merge().ranges),
+		merged:  true,
+	}
+}
+
+// mergeRanges sorts ranges by their respective starting xIP and merges the
+// duplicate or adjacent parts of them. ranges is reused for the result, so
+// it must not be shared with any IPRanges.
+func mergeRanges(ranges []ipRange) []ipRange {
+	if len(ranges) <= 1 {
+		return ranges
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].start.cmp(ranges[j].start) < 0
+	})
+
+	cur := 0
+	for _, r := range ranges[1:] {
+		if ranges[cur].end.cmp(r.start) >= 0 {
+			if ranges[cur].end.cmp(r.end) < 0 {
+				ranges[cur].end = r.end
+			}
+			continue
+		}
+
+		if ranges[cur].end.next().cmp(r.start) == 0 {
+			ranges[cur].end = r.end
+			continue
+		}
+
+		cur++
+		ranges[cur] = r
+	}
+
+	return ranges[:cur+1]
+}
+
+// diffRanges calculates the difference of merged ranges omr and tmr.
+// Neither omr nor tmr is modified.
+func diffRanges(omr, tmr []ipRange) []ipRange {
 	n1, n2 := len(omr), len(tmr)
 	ranges := make([]ipRange, 0, n1+n2)
+	if n1 == 0 {
+		return ranges
+	}
 
+	// a is the part of omr[i] that has not been subtracted yet.
 	i, j := 0, 0
+	a := omr[0]
 	for i < n1 && j < n2 {
 		// The following are all distributions of the difference sets between two
 		// IP range A and B (IP range A - IP range B).
@@ -268,83 +409,65 @@ func (rr *IPRanges) Diff(rs *IPRanges) *IPRanges {
 
 		// *------*
 		//           `------`
-		if omr[i].end.cmp(tmr[j].start) < 0 {
-			ranges = append(ranges, omr[i])
-			i++
+		if a.end.cmp(tmr[j].start) < 0 {
+			ranges = append(ranges, a)
+			if i++; i < n1 {
+				a = omr[i]
+			}
 			continue
 		}
 
 		//           *------*
 		// `------`
-		if omr[i].start.cmp(tmr[j].end) > 0 {
+		if a.start.cmp(tmr[j].end) > 0 {
 			j++
 			continue
 		}
 
-		if omr[i].end.cmp(tmr[j].end) <= 0 {
+		if a.end.cmp(tmr[j].end) <= 0 {
 			// *------*
 			//     `------`
-			if omr[i].start.cmp(tmr[j].start) < 0 {
+			if a.start.cmp(tmr[j].start) < 0 {
 				ranges = append(ranges, ipRange{
-					start: omr[i].start,
+					start: a.start,
 					end:   tmr[j].start.prev(),
 				})
 			}
 
 			//     *--*
 			// `----------`
-			i++
+			if i++; i < n1 {
+				a = omr[i]
+			}
 			continue
 		}
 
 		// *----------*
 		//     `--`
-		if omr[i].start.cmp(tmr[j].start) < 0 {
+		if a.start.cmp(tmr[j].start) < 0 {
 			ranges = append(ranges, ipRange{
-				start: omr[i].start,
+				start: a.start,
 				end:   tmr[j].start.prev(),
 			})
 		}
 
 		//     *------*
 		// `------`
-		omr[i].start = (tmr[j].end.next())
+		a.start = tmr[j].end.next()
 		j++
 	}
 
-	if j == n2 && tmr[j-1].end.cmp(omr[i].end) < 0 {
-		ranges = append(ranges, omr[i])
-	}
-
-	if i+1 < n1 {
+	if i < n1 {
+		ranges = append(ranges, a)
 		ranges = append(ranges, omr[i+1:]...)
 	}
-	rr.ranges = ranges
 
-	return rr
+	return ranges
 }
 
-// Intersect calculates the intersection of IPRanges rr and rs with the
-// same IP version. The result is always merged (ordered and deduplicated).
-//
-//	Input:  [172.18.0.20-30, 172.18.0.1-25] ∩ [172.18.0.5-25]
-//	Output: [172.18.0.5-25]
-func (rr *IPRanges) Intersect(rs *IPRanges) *IPRanges {
-	if rr.version != rs.version {
-		return &IPRanges{
-			version: rr.version,
-		}
-	}
-
-	if len(rr.ranges) == 0 || len(rs.ranges) == 0 {
-		return &IPRanges{
-			version: rr.version,
-		}
-	}
-
-	rs = rs.DeepCopy()
-	omr := rr.Merge().ranges
-	tmr := rs.Merge().ranges
+// intersectRanges calculates the intersection of merged ranges omr and
+// tmr. Neither omr nor tmr is modified.
+func intersectRanges(omr, tmr []ipRange) []ipRange {
 	n1, n2 := len(omr), len(tmr)
 	ranges := make([]ipRange, 0, maxN(n1, n2))
 
@@ -364,9 +487,8 @@ func (rr *IPRanges) Intersect(rs *IPRanges) *IPRanges {
 			j++
 		}
 	}
-	rr.ranges = ranges
 
-	return rr
+	return ranges
 }
 
 // Slice returns a slice of IPRanges, supporting negative indexes.
@@ -443,6 +565,7 @@ func (rr *IPRanges) Slice(start, end *big.Int) *IPRanges {
 		break
 	}
 	rs.ranges = ranges
+	rs.merged = rr.merged
 
 	return rs
 }
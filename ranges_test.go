@@ -2,6 +2,7 @@ package iprange
 
 import (
 	"errors"
+	"fmt"
 	"math/big"
 	"net"
 	"testing"
@@ -27,20 +28,20 @@ var parseTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 1).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 0).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 255).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 0).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 255).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 10).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 1, 10).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 1, 10).To4()),
 				},
 			},
 		},
@@ -58,20 +59,20 @@ var parseTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::1")},
-					end:   xIP{net.ParseIP("fd00::1")},
+					start: ipToXIP(net.ParseIP("fd00::1")),
+					end:   ipToXIP(net.ParseIP("fd00::1")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::0")},
-					end:   xIP{net.ParseIP("fd00::ffff:ffff:ffff:ffff")},
+					start: ipToXIP(net.ParseIP("fd00::0")),
+					end:   ipToXIP(net.ParseIP("fd00::ffff:ffff:ffff:ffff")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::1")},
-					end:   xIP{net.ParseIP("fd00::a")},
+					start: ipToXIP(net.ParseIP("fd00::1")),
+					end:   ipToXIP(net.ParseIP("fd00::a")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::1")},
-					end:   xIP{net.ParseIP("fd00::1:a")},
+					start: ipToXIP(net.ParseIP("fd00::1")),
+					end:   ipToXIP(net.ParseIP("fd00::1:a")),
 				},
 			},
 		},
@@ -88,6 +89,7 @@ var parseTests = []struct {
 	{"start exceeds end", []string{"172.18.0.10-1"}, nil, errInvalidIPRangeFormat},
 	{"start exceeds end", []string{"172.18.0.10-172.18.0.1"}, nil, errInvalidIPRangeFormat},
 	{"dual-stack", []string{"172.18.0.1", "fd00::/64"}, nil, errDualStackIPRanges},
+	{"dual-stack range", []string{"172.18.0.1-fd00::1"}, nil, errInvalidIPRangeFormat},
 }
 
 func TestParse(t *testing.T) {
@@ -110,6 +112,304 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestParseOctets(t *testing.T) {
+	t.Parallel()
+	// 10.0.*.1 expands to 10.0.0.1, 10.0.1.1, ..., 10.0.255.1.
+	wildcard := make([]string, 0, 256)
+	for i := 0; i < 256; i++ {
+		wildcard = append(wildcard, fmt.Sprintf("10.0.%d.1", i))
+	}
+
+	tests := []struct {
+		name string
+		r    string
+		want []string
+	}{
+		{
+			name: "wildcard",
+			r:    "10.0.*.1",
+			want: wildcard,
+		},
+		{
+			name: "trailing wildcards",
+			r:    "10.0.*.*",
+			want: []string{"10.0.0.0/16"},
+		},
+		{
+			name: "all wildcards",
+			r:    "*.*.*.*",
+			want: []string{"0.0.0.0/0"},
+		},
+		{
+			name: "octet ranges",
+			r:    "10.0.1-3.1-254",
+			want: []string{"10.0.1.1-10.0.1.254", "10.0.2.1-10.0.2.254", "10.0.3.1-10.0.3.254"},
+		},
+		{
+			name: "comma list with prefix length",
+			r:    "192.168.0,2,4.0/24",
+			want: []string{"192.168.0.0/24", "192.168.2.0/24", "192.168.4.0/24"},
+		},
+		{
+			name: "merged",
+			r:    "10.0-1.0-10,250-255,5.*",
+			want: []string{"10.0.0.0-10.0.10.255", "10.0.250.0-10.1.10.255", "10.1.250.0-10.1.255.255"},
+		},
+		{
+			name: "last octet",
+			r:    "10.0.0.1,3,5-7",
+			want: []string{"10.0.0.1", "10.0.0.3", "10.0.0.5-10.0.0.7"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ranges, err := Parse(test.r)
+			if err != nil {
+				t.Fatalf("Parse(%q) err %q", test.r, err)
+			}
+
+			if got := ranges.Strings(); !cmp.Equal(got, test.want) {
+				t.Errorf("Parse(%q) = %v, want %v", test.r, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseMasks(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		r    string
+		want []string
+	}{
+		{
+			name: "netmask after slash",
+			r:    "10.0.0.0/255.255.255.0",
+			want: []string{"10.0.0.0/24"},
+		},
+		{
+			name: "netmask after space",
+			r:    "10.0.0.0 255.255.0.0",
+			want: []string{"10.0.0.0/16"},
+		},
+		{
+			name: "host netmask",
+			r:    "10.0.0.1/255.255.255.255",
+			want: []string{"10.0.0.1"},
+		},
+		{
+			name: "netmask keeps host bits",
+			r:    "10.0.0.5/255.255.255.0",
+			want: []string{"10.0.0.5-10.0.0.255"},
+		},
+		{
+			name: "netmask after space keeps host bits",
+			r:    "10.0.0.5 255.255.255.0",
+			want: []string{"10.0.0.5-10.0.0.255"},
+		},
+		{
+			name: "zero netmask",
+			r:    "0.0.0.0/0.0.0.0",
+			want: []string{"0.0.0.0/0"},
+		},
+		{
+			name: "wildcard mask",
+			r:    "10.0.0.0 0.0.0.255",
+			want: []string{"10.0.0.0/24"},
+		},
+		{
+			name: "host wildcard mask",
+			r:    "10.0.0.1  0.0.0.0",
+			want: []string{"10.0.0.1"},
+		},
+		{
+			name: "any wildcard mask",
+			r:    "0.0.0.0 255.255.255.255",
+			want: []string{"0.0.0.0/0"},
+		},
+		{
+			name: "all ones wildcard mask",
+			r:    "10.0.0.1 255.255.255.255",
+			want: []string{"0.0.0.0/0"},
+		},
+		{
+			name: "ignored bits",
+			r:    "10.0.0.77 0.0.0.255",
+			want: []string{"10.0.0.0/24"},
+		},
+		{
+			name: "non-contiguous wildcard mask",
+			r:    "10.0.0.0 0.0.2.255",
+			want: []string{"10.0.0.0/24", "10.0.2.0/24"},
+		},
+		{
+			name: "odd addresses",
+			r:    "10.0.0.1 0.0.0.6",
+			want: []string{"10.0.0.1", "10.0.0.3", "10.0.0.5", "10.0.0.7"},
+		},
+		{
+			name: "IPv6 netmask",
+			r:    "fd00::/ffff:ffff:ffff:ffff::",
+			want: []string{"fd00::/64"},
+		},
+		{
+			name: "IPv6 wildcard mask",
+			r:    "fd00:: ::ffff",
+			want: []string{"fd00::/112"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ranges, err := Parse(test.r)
+			if err != nil {
+				t.Fatalf("Parse(%q) err %q", test.r, err)
+			}
+			if got := ranges.Strings(); !cmp.Equal(got, test.want) {
+				t.Errorf("Parse(%q) = %v, want %v", test.r, got, test.want)
+			}
+		})
+	}
+}
+
+func TestIPRangesWildcardMasks(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		ranges *IPRanges
+		want   []string
+	}{
+		{
+			name:   "CIDR",
+			ranges: mustParse("10.0.0.0/24"),
+			want:   []string{"10.0.0.0 0.0.0.255"},
+		},
+		{
+			name:   "merged",
+			ranges: mustParse("10.0.1.1", "10.0.0.0/24", "10.0.0.100-10.0.1.0"),
+			want:   []string{"10.0.0.0 0.0.0.255", "10.0.1.0 0.0.0.1"},
+		},
+		{
+			name:   "IPv6",
+			ranges: mustParse("fd00::/64"),
+			want:   []string{"fd00:: ::ffff:ffff:ffff:ffff"},
+		},
+		{
+			name:   "zero",
+			ranges: mustParse(),
+			want:   nil,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := test.ranges.WildcardMasks()
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("IPRanges(%v).WildcardMasks() = %v, want %v", test.ranges, got, test.want)
+			}
+
+			ranges, err := Parse(got...)
+			if err != nil {
+				t.Fatalf("Parse(%q) err %q", got, err)
+			}
+			if !ranges.MergeEqual(test.ranges) {
+				t.Errorf("Parse(%q) = %v, want %v", got, ranges, test.ranges)
+			}
+		})
+	}
+}
+
+var parseErrorTests = []struct {
+	name string
+	rs   []string
+	want *ParseError
+}{
+	{"empty", []string{"172.18.0.1", ""}, &ParseError{1, "", ReasonEmpty, errInvalidIPRangeFormat}},
+	{"bad IP", []string{"172.18.0.a"}, &ParseError{0, "172.18.0.a", ReasonBadIP, errInvalidIPRangeFormat}},
+	{"bad CIDR IP", []string{"172.18.0.a/24"}, &ParseError{0, "172.18.0.a/24", ReasonBadIP, errInvalidIPRangeFormat}},
+	{"bad prefix length", []string{"172.18.0.0/33"}, &ParseError{0, "172.18.0.0/33", ReasonBadPrefixLength, errInvalidIPRangeFormat}},
+	{"bad start IP", []string{"172.18.0.a-10"}, &ParseError{0, "172.18.0.a-10", ReasonBadStartIP, errInvalidIPRangeFormat}},
+	{"bad end IP", []string{"fd00::1-x"}, &ParseError{0, "fd00::1-x", ReasonBadEndIP, errInvalidIPRangeFormat}},
+	{"reversed range", []string{"172.18.0.10-1"}, &ParseError{0, "172.18.0.10-1", ReasonReversedRange, errInvalidIPRangeFormat}},
+	{"family mismatch", []string{"172.18.0.1-fd00::1"}, &ParseError{0, "172.18.0.1-fd00::1", ReasonFamilyMismatch, errInvalidIPRangeFormat}},
+	{"dual-stack", []string{"fd00::1", "fd00::2", "172.18.0.1"}, &ParseError{2, "172.18.0.1", ReasonFamilyMismatch, errDualStackIPRanges}},
+	{"bad octet", []string{"10.0.*.256"}, &ParseError{0, "10.0.*.256", ReasonBadIP, errInvalidIPRangeFormat}},
+	{"reversed octet range", []string{"10.0.3-1.1"}, &ParseError{0, "10.0.3-1.1", ReasonReversedRange, errInvalidIPRangeFormat}},
+	{"bad octet prefix length", []string{"10.0.*.0/33"}, &ParseError{0, "10.0.*.0/33", ReasonBadPrefixLength, errInvalidIPRangeFormat}},
+	{"too many ranges", []string{"*.*.*.1"}, &ParseError{0, "*.*.*.1", ReasonTooManyRanges, errInvalidIPRangeFormat}},
+	{"bad mask", []string{"10.0.0.0 255.255.0.a"}, &ParseError{0, "10.0.0.0 255.255.0.a", ReasonBadMask, errInvalidIPRangeFormat}},
+	{"wildcard mask after slash", []string{"10.0.0.0/0.0.0.255"}, &ParseError{0, "10.0.0.0/0.0.0.255", ReasonBadMask, errInvalidIPRangeFormat}},
+	{"non-contiguous netmask", []string{"10.0.0.0/255.0.255.0"}, &ParseError{0, "10.0.0.0/255.0.255.0", ReasonBadMask, errInvalidIPRangeFormat}},
+	{"two IP addresses", []string{"10.0.0.1 10.0.0.5"}, &ParseError{0, "10.0.0.1 10.0.0.5", ReasonBadMask, errInvalidIPRangeFormat}},
+	{"bad masked IP", []string{"10.0.0.a/255.255.0.0"}, &ParseError{0, "10.0.0.a/255.255.0.0", ReasonBadIP, errInvalidIPRangeFormat}},
+	{"mask family mismatch", []string{"10.0.0.0 ffff::"}, &ParseError{0, "10.0.0.0 ffff::", ReasonFamilyMismatch, errInvalidIPRangeFormat}},
+	{"too many wildcard ranges", []string{"10.0.0.0 1.255.255.0"}, &ParseError{0, "10.0.0.0 1.255.255.0", ReasonTooManyRanges, errInvalidIPRangeFormat}},
+}
+
+func TestParseError(t *testing.T) {
+	t.Parallel()
+	for _, test := range parseErrorTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := Parse(test.rs...)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Parse(%q) err %v, want *ParseError", test.rs, err)
+			}
+			if *parseErr != *test.want {
+				t.Fatalf("Parse(%q) err %#v, want %#v", test.rs, parseErr, test.want)
+			}
+			if !errors.Is(err, test.want.err) {
+				t.Fatalf("Parse(%q) err %v, want %v", test.rs, err, test.want.err)
+			}
+		})
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	t.Parallel()
+	rs := []string{"", "172.18.0.1", "172.18.0.10-1", "172.18.0.0/24", "fd00::1", "172.18.0.a"}
+	_, err := ParseAll(rs...)
+	if !IsInvalidIPRangeFormat(err) || !IsDualStackIPRanges(err) {
+		t.Fatalf("ParseAll(%q) err %v, want both errInvalidIPRangeFormat and errDualStackIPRanges", rs, err)
+	}
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("ParseAll(%q) err %v, want joined errors", rs, err)
+	}
+	var indexes []int
+	for _, e := range joined.Unwrap() {
+		var parseErr *ParseError
+		if !errors.As(e, &parseErr) {
+			t.Fatalf("ParseAll(%q) err %v, want *ParseError", rs, e)
+		}
+		indexes = append(indexes, parseErr.Index)
+	}
+	if want := []int{0, 2, 4, 5}; !cmp.Equal(indexes, want) {
+		t.Fatalf("ParseAll(%q) err indexes %v, want %v", rs, indexes, want)
+	}
+
+	ranges, err := ParseAll("172.18.0.1", "172.18.0.0/24")
+	if err != nil {
+		t.Fatalf("ParseAll() err %q", err)
+	}
+	if ranges.String() != "[172.18.0.1 172.18.0.0/24]" {
+		t.Fatalf("ParseAll() = %v, want [172.18.0.1 172.18.0.0/24]", ranges)
+	}
+}
+
 var ipRangesVersionTests = []struct {
 	name   string
 	ranges *IPRanges
@@ -121,8 +421,8 @@ var ipRangesVersionTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 1).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
 				},
 			},
 		},
@@ -134,8 +434,8 @@ var ipRangesVersionTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::1")},
-					end:   xIP{net.ParseIP("fd00::1")},
+					start: ipToXIP(net.ParseIP("fd00::1")),
+					end:   ipToXIP(net.ParseIP("fd00::1")),
 				},
 			},
 		},
@@ -174,8 +474,8 @@ var ipRangesContainsTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 3).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 3).To4()),
 				},
 			},
 		},
@@ -188,8 +488,8 @@ var ipRangesContainsTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::1")},
-					end:   xIP{net.ParseIP("fd00::3")},
+					start: ipToXIP(net.ParseIP("fd00::1")),
+					end:   ipToXIP(net.ParseIP("fd00::3")),
 				},
 			},
 		},
@@ -202,8 +502,8 @@ var ipRangesContainsTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 3).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 3).To4()),
 				},
 			},
 		},
@@ -216,8 +516,8 @@ var ipRangesContainsTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::1")},
-					end:   xIP{net.ParseIP("fd00::3")},
+					start: ipToXIP(net.ParseIP("fd00::1")),
+					end:   ipToXIP(net.ParseIP("fd00::3")),
 				},
 			},
 		},
@@ -230,8 +530,8 @@ var ipRangesContainsTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 3).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 3).To4()),
 				},
 			},
 		},
@@ -244,8 +544,8 @@ var ipRangesContainsTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::1")},
-					end:   xIP{net.ParseIP("fd00::3")},
+					start: ipToXIP(net.ParseIP("fd00::1")),
+					end:   ipToXIP(net.ParseIP("fd00::3")),
 				},
 			},
 		},
@@ -264,8 +564,8 @@ var ipRangesContainsTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 3).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 3).To4()),
 				},
 			},
 		},
@@ -288,6 +588,85 @@ func TestIPRangesContains(t *testing.T) {
 	}
 }
 
+var ipRangesSearchTests = []struct {
+	name string
+	ip   net.IP
+	want bool
+}{
+	{"first start", net.IPv4(172, 18, 0, 1), true},
+	{"first end", net.IPv4(172, 18, 0, 10), true},
+	{"middle", net.IPv4(172, 18, 1, 128), true},
+	{"last end", net.IPv4(172, 18, 3, 255), true},
+	{"before all", net.IPv4(172, 18, 0, 0), false},
+	{"in gap", net.IPv4(172, 18, 0, 11), false},
+	{"after all", net.IPv4(172, 18, 4, 0), false},
+	{"diff version", net.ParseIP("fd00::1"), false},
+	{"invalid IP", nil, false},
+}
+
+func TestIPRangesContainsMerged(t *testing.T) {
+	t.Parallel()
+	ranges, err := Parse("172.18.3.0/24", "172.18.0.1-10", "172.18.1.0/24", "172.18.0.5-7")
+	if err != nil {
+		t.Fatalf("Parse() err %q", err)
+	}
+	merged := ranges.DeepCopy().Merge()
+
+	for _, test := range ipRangesSearchTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if contains := ranges.Contains(test.ip); contains != test.want {
+				t.Fatalf("IPRanges(%v).Contains(%v) = %v, want %v", ranges, test.ip, contains, test.want)
+			}
+			if contains := merged.Contains(test.ip); contains != test.want {
+				t.Fatalf("IPRanges(%v).Merge().Contains(%v) = %v, want %v", ranges, test.ip, contains, test.want)
+			}
+		})
+	}
+}
+
+func TestIPRangesContainsAllocs(t *testing.T) {
+	ranges, err := Parse("172.18.3.0/24", "172.18.0.1-10", "172.18.1.0/24")
+	if err != nil {
+		t.Fatalf("Parse() err %q", err)
+	}
+	merged := ranges.Merge()
+
+	ip := net.IPv4(172, 18, 1, 128)
+	if allocs := testing.AllocsPerRun(100, func() { merged.Contains(ip) }); allocs != 0 {
+		t.Fatalf("IPRanges(%v).Contains(%v) allocs %v, want 0", merged, ip, allocs)
+	}
+}
+
+func TestIPRangesFilter(t *testing.T) {
+	t.Parallel()
+	ranges, err := Parse("172.18.3.0/24", "172.18.0.1-10")
+	if err != nil {
+		t.Fatalf("Parse() err %q", err)
+	}
+
+	ips := []net.IP{
+		net.IPv4(172, 18, 3, 1),
+		net.IPv4(172, 18, 0, 11),
+		net.ParseIP("fd00::1"),
+		net.IPv4(172, 18, 0, 1),
+		nil,
+	}
+	want := []net.IP{
+		net.IPv4(172, 18, 3, 1),
+		net.IPv4(172, 18, 0, 1),
+	}
+
+	filtered := ranges.Filter(ips)
+	if !cmp.Equal(filtered, want) {
+		t.Fatalf("IPRanges(%v).Filter(%v) = %v, want %v", ranges, ips, filtered, want)
+	}
+	if !cmp.Equal(ranges.Strings(), []string{"172.18.3.0/24", "172.18.0.1-172.18.0.10"}) {
+		t.Fatalf("IPRanges.Filter() changed IPRanges to %v", ranges)
+	}
+}
+
 var ipRangesMergeEqualTests = []struct {
 	name    string
 	rangesX *IPRanges
@@ -300,12 +679,12 @@ var ipRangesMergeEqualTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 100).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 255).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 100).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 255).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 0).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 200).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 0).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 200).To4()),
 				},
 			},
 		},
@@ -313,8 +692,8 @@ var ipRangesMergeEqualTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 0).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 255).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 0).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 255).To4()),
 				},
 			},
 		},
@@ -326,12 +705,12 @@ var ipRangesMergeEqualTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::aa")},
-					end:   xIP{net.ParseIP("fd00::ff")},
+					start: ipToXIP(net.ParseIP("fd00::aa")),
+					end:   ipToXIP(net.ParseIP("fd00::ff")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::0")},
-					end:   xIP{net.ParseIP("fd00::dd")},
+					start: ipToXIP(net.ParseIP("fd00::0")),
+					end:   ipToXIP(net.ParseIP("fd00::dd")),
 				},
 			},
 		},
@@ -339,8 +718,8 @@ var ipRangesMergeEqualTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::0")},
-					end:   xIP{net.ParseIP("fd00::ff")},
+					start: ipToXIP(net.ParseIP("fd00::0")),
+					end:   ipToXIP(net.ParseIP("fd00::ff")),
 				},
 			},
 		},
@@ -358,8 +737,8 @@ var ipRangesMergeEqualTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 0).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 255).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 0).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 255).To4()),
 				},
 			},
 		},
@@ -367,8 +746,8 @@ var ipRangesMergeEqualTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::0")},
-					end:   xIP{net.ParseIP("fd00::ff")},
+					start: ipToXIP(net.ParseIP("fd00::0")),
+					end:   ipToXIP(net.ParseIP("fd00::ff")),
 				},
 			},
 		},
@@ -402,12 +781,12 @@ var ipRangesEqualTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 100).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 255).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 100).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 255).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 0).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 200).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 0).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 200).To4()),
 				},
 			},
 		},
@@ -415,12 +794,12 @@ var ipRangesEqualTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 100).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 255).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 100).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 255).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 0).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 200).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 0).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 200).To4()),
 				},
 			},
 		},
@@ -432,12 +811,12 @@ var ipRangesEqualTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::aa")},
-					end:   xIP{net.ParseIP("fd00::ff")},
+					start: ipToXIP(net.ParseIP("fd00::aa")),
+					end:   ipToXIP(net.ParseIP("fd00::ff")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::0")},
-					end:   xIP{net.ParseIP("fd00::dd")},
+					start: ipToXIP(net.ParseIP("fd00::0")),
+					end:   ipToXIP(net.ParseIP("fd00::dd")),
 				},
 			},
 		},
@@ -445,12 +824,12 @@ var ipRangesEqualTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::aa")},
-					end:   xIP{net.ParseIP("fd00::ff")},
+					start: ipToXIP(net.ParseIP("fd00::aa")),
+					end:   ipToXIP(net.ParseIP("fd00::ff")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::0")},
-					end:   xIP{net.ParseIP("fd00::dd")},
+					start: ipToXIP(net.ParseIP("fd00::0")),
+					end:   ipToXIP(net.ParseIP("fd00::dd")),
 				},
 			},
 		},
@@ -468,12 +847,12 @@ var ipRangesEqualTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 100).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 255).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 100).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 255).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 0).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 200).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 0).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 200).To4()),
 				},
 			},
 		},
@@ -481,8 +860,8 @@ var ipRangesEqualTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 0).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 255).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 0).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 255).To4()),
 				},
 			},
 		},
@@ -494,12 +873,12 @@ var ipRangesEqualTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::aa")},
-					end:   xIP{net.ParseIP("fd00::ff")},
+					start: ipToXIP(net.ParseIP("fd00::aa")),
+					end:   ipToXIP(net.ParseIP("fd00::ff")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::0")},
-					end:   xIP{net.ParseIP("fd00::dd")},
+					start: ipToXIP(net.ParseIP("fd00::0")),
+					end:   ipToXIP(net.ParseIP("fd00::dd")),
 				},
 			},
 		},
@@ -507,12 +886,12 @@ var ipRangesEqualTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::0")},
-					end:   xIP{net.ParseIP("fd00::aa")},
+					start: ipToXIP(net.ParseIP("fd00::0")),
+					end:   ipToXIP(net.ParseIP("fd00::aa")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::ab")},
-					end:   xIP{net.ParseIP("fd00::ff")},
+					start: ipToXIP(net.ParseIP("fd00::ab")),
+					end:   ipToXIP(net.ParseIP("fd00::ff")),
 				},
 			},
 		},
@@ -524,8 +903,8 @@ var ipRangesEqualTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 0).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 255).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 0).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 255).To4()),
 				},
 			},
 		},
@@ -533,8 +912,8 @@ var ipRangesEqualTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::0")},
-					end:   xIP{net.ParseIP("fd00::ff")},
+					start: ipToXIP(net.ParseIP("fd00::0")),
+					end:   ipToXIP(net.ParseIP("fd00::ff")),
 				},
 			},
 		},
@@ -569,12 +948,12 @@ var ipRangesSizeTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 100).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 255).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 100).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 255).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 0).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 200).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 0).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 200).To4()),
 				},
 			},
 		},
@@ -586,8 +965,8 @@ var ipRangesSizeTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::1")},
-					end:   xIP{net.ParseIP("fd00::ffff:ffff:ffff:ffff")},
+					start: ipToXIP(net.ParseIP("fd00::1")),
+					end:   ipToXIP(net.ParseIP("fd00::ffff:ffff:ffff:ffff")),
 				},
 			},
 		},
@@ -625,20 +1004,20 @@ var ipRangesMergeTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 100).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 210).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 100).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 210).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 0).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 200).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 0).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 200).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 211).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 211).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 211).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 211).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 220).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 230).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 220).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 230).To4()),
 				},
 			},
 		},
@@ -646,12 +1025,12 @@ var ipRangesMergeTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 0).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 211).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 0).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 211).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 220).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 230).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 220).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 230).To4()),
 				},
 			},
 		},
@@ -662,8 +1041,8 @@ var ipRangesMergeTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::1")},
-					end:   xIP{net.ParseIP("fd00::ff")},
+					start: ipToXIP(net.ParseIP("fd00::1")),
+					end:   ipToXIP(net.ParseIP("fd00::ff")),
 				},
 			},
 		},
@@ -671,8 +1050,8 @@ var ipRangesMergeTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::1")},
-					end:   xIP{net.ParseIP("fd00::ff")},
+					start: ipToXIP(net.ParseIP("fd00::1")),
+					end:   ipToXIP(net.ParseIP("fd00::ff")),
 				},
 			},
 		},
@@ -705,12 +1084,12 @@ var ipRangesUnionTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 20).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 25).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 20).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 25).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 10).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
 				},
 			},
 		},
@@ -718,8 +1097,8 @@ var ipRangesUnionTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 5).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 15).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 5).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 15).To4()),
 				},
 			},
 		},
@@ -727,12 +1106,12 @@ var ipRangesUnionTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 15).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 15).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 20).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 25).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 20).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 25).To4()),
 				},
 			},
 		},
@@ -743,12 +1122,12 @@ var ipRangesUnionTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::14")},
-					end:   xIP{net.ParseIP("fd00::19")},
+					start: ipToXIP(net.ParseIP("fd00::14")),
+					end:   ipToXIP(net.ParseIP("fd00::19")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::1")},
-					end:   xIP{net.ParseIP("fd00::a")},
+					start: ipToXIP(net.ParseIP("fd00::1")),
+					end:   ipToXIP(net.ParseIP("fd00::a")),
 				},
 			},
 		},
@@ -756,8 +1135,8 @@ var ipRangesUnionTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::5")},
-					end:   xIP{net.ParseIP("fd00::f")},
+					start: ipToXIP(net.ParseIP("fd00::5")),
+					end:   ipToXIP(net.ParseIP("fd00::f")),
 				},
 			},
 		},
@@ -765,12 +1144,12 @@ var ipRangesUnionTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::1")},
-					end:   xIP{net.ParseIP("fd00::f")},
+					start: ipToXIP(net.ParseIP("fd00::1")),
+					end:   ipToXIP(net.ParseIP("fd00::f")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::14")},
-					end:   xIP{net.ParseIP("fd00::19")},
+					start: ipToXIP(net.ParseIP("fd00::14")),
+					end:   ipToXIP(net.ParseIP("fd00::19")),
 				},
 			},
 		},
@@ -781,8 +1160,8 @@ var ipRangesUnionTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 20).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 25).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 20).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 25).To4()),
 				},
 			},
 		},
@@ -790,8 +1169,8 @@ var ipRangesUnionTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::0")},
-					end:   xIP{net.ParseIP("fd00::5")},
+					start: ipToXIP(net.ParseIP("fd00::0")),
+					end:   ipToXIP(net.ParseIP("fd00::5")),
 				},
 			},
 		},
@@ -799,8 +1178,8 @@ var ipRangesUnionTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 20).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 25).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 20).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 25).To4()),
 				},
 			},
 		},
@@ -833,20 +1212,20 @@ var ipRangesDiffTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 10).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 20).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 20).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 5).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 5).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 25).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 30).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 25).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 30).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 40).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 50).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 40).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 50).To4()),
 				},
 			},
 		},
@@ -854,16 +1233,16 @@ var ipRangesDiffTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 15).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 15).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 15).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 15).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 8).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 12).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 8).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 12).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 18).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 22).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 18).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 22).To4()),
 				},
 			},
 		},
@@ -871,24 +1250,24 @@ var ipRangesDiffTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 5).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 5).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 13).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 14).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 13).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 14).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 16).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 17).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 16).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 17).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 25).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 30).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 25).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 30).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 40).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 50).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 40).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 50).To4()),
 				},
 			},
 		},
@@ -899,20 +1278,20 @@ var ipRangesDiffTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::a")},
-					end:   xIP{net.ParseIP("fd00::14")},
+					start: ipToXIP(net.ParseIP("fd00::a")),
+					end:   ipToXIP(net.ParseIP("fd00::14")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::1")},
-					end:   xIP{net.ParseIP("fd00::5")},
+					start: ipToXIP(net.ParseIP("fd00::1")),
+					end:   ipToXIP(net.ParseIP("fd00::5")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::19")},
-					end:   xIP{net.ParseIP("fd00::1e")},
+					start: ipToXIP(net.ParseIP("fd00::19")),
+					end:   ipToXIP(net.ParseIP("fd00::1e")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::28")},
-					end:   xIP{net.ParseIP("fd00::32")},
+					start: ipToXIP(net.ParseIP("fd00::28")),
+					end:   ipToXIP(net.ParseIP("fd00::32")),
 				},
 			},
 		},
@@ -920,16 +1299,16 @@ var ipRangesDiffTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::f")},
-					end:   xIP{net.ParseIP("fd00::f")},
+					start: ipToXIP(net.ParseIP("fd00::f")),
+					end:   ipToXIP(net.ParseIP("fd00::f")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::8")},
-					end:   xIP{net.ParseIP("fd00::c")},
+					start: ipToXIP(net.ParseIP("fd00::8")),
+					end:   ipToXIP(net.ParseIP("fd00::c")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::12")},
-					end:   xIP{net.ParseIP("fd00::16")},
+					start: ipToXIP(net.ParseIP("fd00::12")),
+					end:   ipToXIP(net.ParseIP("fd00::16")),
 				},
 			},
 		},
@@ -937,24 +1316,24 @@ var ipRangesDiffTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::1")},
-					end:   xIP{net.ParseIP("fd00::5")},
+					start: ipToXIP(net.ParseIP("fd00::1")),
+					end:   ipToXIP(net.ParseIP("fd00::5")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::d")},
-					end:   xIP{net.ParseIP("fd00::e")},
+					start: ipToXIP(net.ParseIP("fd00::d")),
+					end:   ipToXIP(net.ParseIP("fd00::e")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::10")},
-					end:   xIP{net.ParseIP("fd00::11")},
+					start: ipToXIP(net.ParseIP("fd00::10")),
+					end:   ipToXIP(net.ParseIP("fd00::11")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::19")},
-					end:   xIP{net.ParseIP("fd00::1e")},
+					start: ipToXIP(net.ParseIP("fd00::19")),
+					end:   ipToXIP(net.ParseIP("fd00::1e")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::28")},
-					end:   xIP{net.ParseIP("fd00::32")},
+					start: ipToXIP(net.ParseIP("fd00::28")),
+					end:   ipToXIP(net.ParseIP("fd00::32")),
 				},
 			},
 		},
@@ -965,8 +1344,8 @@ var ipRangesDiffTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 20).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 25).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 20).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 25).To4()),
 				},
 			},
 		},
@@ -974,8 +1353,8 @@ var ipRangesDiffTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::0")},
-					end:   xIP{net.ParseIP("fd00::5")},
+					start: ipToXIP(net.ParseIP("fd00::0")),
+					end:   ipToXIP(net.ParseIP("fd00::5")),
 				},
 			},
 		},
@@ -983,8 +1362,8 @@ var ipRangesDiffTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 20).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 25).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 20).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 25).To4()),
 				},
 			},
 		},
@@ -996,8 +1375,8 @@ var ipRangesDiffTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::0")},
-					end:   xIP{net.ParseIP("fd00::5")},
+					start: ipToXIP(net.ParseIP("fd00::0")),
+					end:   ipToXIP(net.ParseIP("fd00::5")),
 				},
 			},
 		},
@@ -1009,8 +1388,8 @@ var ipRangesDiffTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 20).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 25).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 20).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 25).To4()),
 				},
 			},
 		},
@@ -1019,8 +1398,8 @@ var ipRangesDiffTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 20).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 25).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 20).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 25).To4()),
 				},
 			},
 		},
@@ -1053,20 +1432,20 @@ var ipRangesIntersectTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 10).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 20).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 20).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 5).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 5).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 25).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 30).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 25).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 30).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 40).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 50).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 40).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 50).To4()),
 				},
 			},
 		},
@@ -1074,16 +1453,16 @@ var ipRangesIntersectTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 15).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 15).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 15).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 15).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 8).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 12).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 8).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 12).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 18).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 22).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 18).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 22).To4()),
 				},
 			},
 		},
@@ -1091,16 +1470,16 @@ var ipRangesIntersectTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 10).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 12).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 12).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 15).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 15).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 15).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 15).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 18).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 20).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 18).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 20).To4()),
 				},
 			},
 		},
@@ -1111,16 +1490,16 @@ var ipRangesIntersectTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::f")},
-					end:   xIP{net.ParseIP("fd00::f")},
+					start: ipToXIP(net.ParseIP("fd00::f")),
+					end:   ipToXIP(net.ParseIP("fd00::f")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::8")},
-					end:   xIP{net.ParseIP("fd00::c")},
+					start: ipToXIP(net.ParseIP("fd00::8")),
+					end:   ipToXIP(net.ParseIP("fd00::c")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::12")},
-					end:   xIP{net.ParseIP("fd00::16")},
+					start: ipToXIP(net.ParseIP("fd00::12")),
+					end:   ipToXIP(net.ParseIP("fd00::16")),
 				},
 			},
 		},
@@ -1128,20 +1507,20 @@ var ipRangesIntersectTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::a")},
-					end:   xIP{net.ParseIP("fd00::14")},
+					start: ipToXIP(net.ParseIP("fd00::a")),
+					end:   ipToXIP(net.ParseIP("fd00::14")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::1")},
-					end:   xIP{net.ParseIP("fd00::5")},
+					start: ipToXIP(net.ParseIP("fd00::1")),
+					end:   ipToXIP(net.ParseIP("fd00::5")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::19")},
-					end:   xIP{net.ParseIP("fd00::1e")},
+					start: ipToXIP(net.ParseIP("fd00::19")),
+					end:   ipToXIP(net.ParseIP("fd00::1e")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::28")},
-					end:   xIP{net.ParseIP("fd00::32")},
+					start: ipToXIP(net.ParseIP("fd00::28")),
+					end:   ipToXIP(net.ParseIP("fd00::32")),
 				},
 			},
 		},
@@ -1149,16 +1528,16 @@ var ipRangesIntersectTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::a")},
-					end:   xIP{net.ParseIP("fd00::c")},
+					start: ipToXIP(net.ParseIP("fd00::a")),
+					end:   ipToXIP(net.ParseIP("fd00::c")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::f")},
-					end:   xIP{net.ParseIP("fd00::f")},
+					start: ipToXIP(net.ParseIP("fd00::f")),
+					end:   ipToXIP(net.ParseIP("fd00::f")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::12")},
-					end:   xIP{net.ParseIP("fd00::14")},
+					start: ipToXIP(net.ParseIP("fd00::12")),
+					end:   ipToXIP(net.ParseIP("fd00::14")),
 				},
 			},
 		},
@@ -1169,8 +1548,8 @@ var ipRangesIntersectTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 20).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 25).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 20).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 25).To4()),
 				},
 			},
 		},
@@ -1178,8 +1557,8 @@ var ipRangesIntersectTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::0")},
-					end:   xIP{net.ParseIP("fd00::5")},
+					start: ipToXIP(net.ParseIP("fd00::0")),
+					end:   ipToXIP(net.ParseIP("fd00::5")),
 				},
 			},
 		},
@@ -1192,8 +1571,8 @@ var ipRangesIntersectTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::0")},
-					end:   xIP{net.ParseIP("fd00::5")},
+					start: ipToXIP(net.ParseIP("fd00::0")),
+					end:   ipToXIP(net.ParseIP("fd00::5")),
 				},
 			},
 		},
@@ -1205,8 +1584,8 @@ var ipRangesIntersectTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 20).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 25).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 20).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 25).To4()),
 				},
 			},
 		},
@@ -1242,8 +1621,8 @@ var ipRangesSliceTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 10).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 20).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 20).To4()),
 				},
 			},
 		},
@@ -1253,8 +1632,8 @@ var ipRangesSliceTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 10).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 12).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 12).To4()),
 				},
 			},
 		},
@@ -1265,16 +1644,16 @@ var ipRangesSliceTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::f")},
-					end:   xIP{net.ParseIP("fd00::f")},
+					start: ipToXIP(net.ParseIP("fd00::f")),
+					end:   ipToXIP(net.ParseIP("fd00::f")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::8")},
-					end:   xIP{net.ParseIP("fd00::9")},
+					start: ipToXIP(net.ParseIP("fd00::8")),
+					end:   ipToXIP(net.ParseIP("fd00::9")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::12")},
-					end:   xIP{net.ParseIP("fd00::16")},
+					start: ipToXIP(net.ParseIP("fd00::12")),
+					end:   ipToXIP(net.ParseIP("fd00::16")),
 				},
 			},
 		},
@@ -1284,12 +1663,12 @@ var ipRangesSliceTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::8")},
-					end:   xIP{net.ParseIP("fd00::9")},
+					start: ipToXIP(net.ParseIP("fd00::8")),
+					end:   ipToXIP(net.ParseIP("fd00::9")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::12")},
-					end:   xIP{net.ParseIP("fd00::12")},
+					start: ipToXIP(net.ParseIP("fd00::12")),
+					end:   ipToXIP(net.ParseIP("fd00::12")),
 				},
 			},
 		},
@@ -1300,16 +1679,16 @@ var ipRangesSliceTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 10).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 20).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 20).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 5).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 5).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 25).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 30).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 25).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 30).To4()),
 				},
 			},
 		},
@@ -1319,16 +1698,16 @@ var ipRangesSliceTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 11).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 20).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 11).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 20).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 5).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 5).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 25).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 29).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 25).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 29).To4()),
 				},
 			},
 		},
@@ -1339,8 +1718,8 @@ var ipRangesSliceTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 5).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 5).To4()),
 				},
 			},
 		},
@@ -1350,8 +1729,8 @@ var ipRangesSliceTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 5).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 5).To4()),
 				},
 			},
 		},
@@ -1362,8 +1741,8 @@ var ipRangesSliceTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 5).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 5).To4()),
 				},
 			},
 		},
@@ -1377,8 +1756,8 @@ var ipRangesSliceTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 5).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 5).To4()),
 				},
 			},
 		},
@@ -1392,8 +1771,8 @@ var ipRangesSliceTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 1).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 5).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 1).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 5).To4()),
 				},
 			},
 		},
@@ -1433,15 +1812,15 @@ var ipRangesIsOverlapTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 10).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 10).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 20).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 10).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 20).To4()),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 15).To4()},
-					end:   xIP{net.IPv4(172, 18, 0, 25).To4()},
+					start: ipToXIP(net.IPv4(172, 18, 0, 15).To4()),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 25).To4()),
 				},
 			},
 		},
@@ -1453,12 +1832,12 @@ var ipRangesIsOverlapTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::ab")},
-					end:   xIP{net.ParseIP("fd00::ff")},
+					start: ipToXIP(net.ParseIP("fd00::ab")),
+					end:   ipToXIP(net.ParseIP("fd00::ff")),
 				},
 				{
-					start: xIP{net.ParseIP("fd00::0")},
-					end:   xIP{net.ParseIP("fd00::aa")},
+					start: ipToXIP(net.ParseIP("fd00::0")),
+					end:   ipToXIP(net.ParseIP("fd00::aa")),
 				},
 			},
 		},
@@ -1496,12 +1875,12 @@ var ipRangesStringTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 100)},
-					end:   xIP{net.IPv4(172, 18, 0, 255)},
+					start: ipToXIP(net.IPv4(172, 18, 0, 100)),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 255)),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 0)},
-					end:   xIP{net.IPv4(172, 18, 0, 200)},
+					start: ipToXIP(net.IPv4(172, 18, 0, 0)),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 200)),
 				},
 			},
 		},
@@ -1513,8 +1892,8 @@ var ipRangesStringTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 100)},
-					end:   xIP{net.IPv4(172, 18, 0, 255)},
+					start: ipToXIP(net.IPv4(172, 18, 0, 100)),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 255)),
 				},
 			},
 		},
@@ -1526,8 +1905,8 @@ var ipRangesStringTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::")},
-					end:   xIP{net.ParseIP("fd00::ff")},
+					start: ipToXIP(net.ParseIP("fd00::")),
+					end:   ipToXIP(net.ParseIP("fd00::ff")),
 				},
 			},
 		},
@@ -1539,8 +1918,8 @@ var ipRangesStringTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::1")},
-					end:   xIP{net.ParseIP("fd00::1")},
+					start: ipToXIP(net.ParseIP("fd00::1")),
+					end:   ipToXIP(net.ParseIP("fd00::1")),
 				},
 			},
 		},
@@ -1578,12 +1957,12 @@ var ipRangesStringsTests = []struct {
 			version: IPv4,
 			ranges: []ipRange{
 				{
-					start: xIP{net.IPv4(172, 18, 0, 100)},
-					end:   xIP{net.IPv4(172, 18, 0, 255)},
+					start: ipToXIP(net.IPv4(172, 18, 0, 100)),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 255)),
 				},
 				{
-					start: xIP{net.IPv4(172, 18, 0, 0)},
-					end:   xIP{net.IPv4(172, 18, 0, 200)},
+					start: ipToXIP(net.IPv4(172, 18, 0, 0)),
+					end:   ipToXIP(net.IPv4(172, 18, 0, 200)),
 				},
 			},
 		},
@@ -1598,8 +1977,8 @@ var ipRangesStringsTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::")},
-					end:   xIP{net.ParseIP("fd00::ff")},
+					start: ipToXIP(net.ParseIP("fd00::")),
+					end:   ipToXIP(net.ParseIP("fd00::ff")),
 				},
 			},
 		},
@@ -1611,8 +1990,8 @@ var ipRangesStringsTests = []struct {
 			version: IPv6,
 			ranges: []ipRange{
 				{
-					start: xIP{net.ParseIP("fd00::1")},
-					end:   xIP{net.ParseIP("fd00::1")},
+					start: ipToXIP(net.ParseIP("fd00::1")),
+					end:   ipToXIP(net.ParseIP("fd00::1")),
 				},
 			},
 		},
@@ -1638,3 +2017,92 @@ func TestIPRangesStrings(t *testing.T) {
 		})
 	}
 }
+
+func TestIPRangesImmutable(t *testing.T) {
+	t.Parallel()
+	rsX := []string{"172.18.0.20-30", "172.18.0.1-25", "172.18.0.100"}
+	rsY := []string{"172.18.0.50-60", "172.18.0.5-25"}
+	rangesX, err := Parse(rsX...)
+	if err != nil {
+		t.Fatalf("Parse(%q) err %q", rsX, err)
+	}
+	rangesY, err := Parse(rsY...)
+	if err != nil {
+		t.Fatalf("Parse(%q) err %q", rsY, err)
+	}
+	wantX, wantY := rangesX.DeepCopy(), rangesY.DeepCopy()
+
+	ops := map[string]func(){
+		"Merge":     func() { rangesX.Merge() },
+		"Union":     func() { rangesX.Union(rangesY) },
+		"Diff":      func() { rangesX.Diff(rangesY) },
+		"Intersect": func() { rangesX.Intersect(rangesY) },
+		"Slice":     func() { rangesX.Slice(big.NewInt(1), big.NewInt(-2)) },
+		"IsOverlap": func() { rangesX.IsOverlap() },
+		"Filter":    func() { rangesX.Filter([]net.IP{net.IPv4(172, 18, 0, 1)}) },
+	}
+	for name, op := range ops {
+		op()
+		if !rangesX.Equal(wantX) || !rangesY.Equal(wantY) {
+			t.Fatalf("IPRanges.%s() changed IPRanges to %v and %v, want %v and %v", name, rangesX, rangesY, wantX, wantY)
+		}
+	}
+
+	// The results must not share anything with the parameters either.
+	union := rangesX.Union(rangesY)
+	union.Union(rangesX)
+	diff := union.Diff(rangesY)
+	diff.Intersect(rangesX)
+	if !rangesX.Equal(wantX) || !rangesY.Equal(wantY) {
+		t.Fatalf("IPRanges changed to %v and %v, want %v and %v", rangesX, rangesY, wantX, wantY)
+	}
+}
+
+func BenchmarkIPRangesMerge(b *testing.B) {
+	v4s := make([]string, 0, 4096)
+	v6s := make([]string, 0, 4096)
+	for i := 4095; i >= 0; i-- {
+		v4s = append(v4s, fmt.Sprintf("172.%d.%d.0/25", 16+i/256, i%256))
+		v6s = append(v6s, fmt.Sprintf("fd00::%x:0-ff", i))
+	}
+
+	for _, rs := range [][]string{v4s, v6s} {
+		ranges, err := Parse(rs...)
+		if err != nil {
+			b.Fatalf("Parse() err %q", err)
+		}
+		b.Run(ranges.Version().String(), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				ranges.Merge()
+			}
+		})
+	}
+}
+
+func BenchmarkIPRangesContains(b *testing.B) {
+	rs := make([]string, 0, 4096)
+	for i := 0; i < 4096; i++ {
+		rs = append(rs, fmt.Sprintf("172.%d.%d.0/25", 16+i/256, i%256))
+	}
+	ranges, err := Parse(rs...)
+	if err != nil {
+		b.Fatalf("Parse() err %q", err)
+	}
+	ip := net.IPv4(172, 31, 255, 1)
+
+	b.Run("linear", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ranges.Contains(ip)
+		}
+	})
+
+	merged := ranges.DeepCopy().Merge()
+	b.Run("merged", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			merged.Contains(ip)
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package iprange
+
+// ReconcileReport is the result of Reconcile, which compares the recorded
+// reservations of IP addresses with the IP addresses observed in use.
+type ReconcileReport struct {
+	// InUse are the IP addresses both recorded and observed.
+	InUse *IPRanges
+
+	// Leaked are the IP addresses recorded but not observed, whose
+	// reservations can usually be released.
+	Leaked *IPRanges
+
+	// Unknown are the IP addresses observed but not recorded, which are in
+	// use without any reservation.
+	Unknown *IPRanges
+
+	// LeakedOwners are the owners of the leaked IP addresses, along with
+	// the IP addresses each of them leaks.
+	LeakedOwners map[string]*IPRanges
+}
+
+// Clean reports whether ReconcileReport r has neither leaked nor unknown
+// IP addresses.
+func (r *ReconcileReport) Clean() bool {
+	return len(r.Leaked.ranges) == 0 && len(r.Unknown.ranges) == 0
+}
+
+// Reconcile compares the recorded reservations with IPRanges observed, the
+// IP addresses actually in use, such as after an outage. Reservations can
+// be obtained from Store.Reservations.
+//
+// The recorded and observed IP addresses must be of the same IP version,
+// otherwise the error errDualStackIPRanges will be returned. And the error
+// errInvalidIPRangeFormat occurs when any reservation has an invalid IP
+// address.
+func Reconcile(recorded []Reservation, observed *IPRanges) (*ReconcileReport, error) {
+	var all IPRangesBuilder
+	owners := make(map[string]*IPRangesBuilder)
+	for _, r := range recorded {
+		all.AddIP(r.IP)
+
+		b, ok := owners[r.Owner]
+		if !ok {
+			b = &IPRangesBuilder{}
+			owners[r.Owner] = b
+		}
+		b.AddIP(r.IP)
+	}
+	rs, err := all.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rs.ranges) != 0 && len(observed.ranges) != 0 && rs.version != observed.version {
+		return nil, errDualStackIPRanges
+	}
+
+	report := &ReconcileReport{
+		InUse:        rs.Intersect(observed),
+		Leaked:       rs.Diff(observed),
+		Unknown:      observed.Diff(rs),
+		LeakedOwners: make(map[string]*IPRanges),
+	}
+	for owner, b := range owners {
+		owned, _ := b.Build()
+		if leaked := owned.Intersect(report.Leaked); len(leaked.ranges) != 0 {
+			report.LeakedOwners[owner] = leaked
+		}
+	}
+
+	return report, nil
+}
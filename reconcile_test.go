@@ -0,0 +1,118 @@
+package iprange
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+var reconcileTests = []struct {
+	name         string
+	recorded     []Reservation
+	observed     []string
+	inUse        string
+	leaked       string
+	unknown      string
+	leakedOwners map[string]string
+	err          error
+}{
+	{
+		name: "leaked and unknown",
+		recorded: []Reservation{
+			{net.ParseIP("172.18.0.1"), "a"},
+			{net.ParseIP("172.18.0.2"), "a"},
+			{net.ParseIP("172.18.0.3"), "b"},
+			{net.ParseIP("172.18.0.4"), "c"},
+		},
+		observed:     []string{"172.18.0.2-4", "172.18.0.10"},
+		inUse:        "172.18.0.2-172.18.0.4",
+		leaked:       "172.18.0.1",
+		unknown:      "172.18.0.10",
+		leakedOwners: map[string]string{"a": "172.18.0.1"},
+	},
+	{
+		name: "clean",
+		recorded: []Reservation{
+			{net.ParseIP("fd00::1"), "a"},
+			{net.ParseIP("fd00::2"), "b"},
+		},
+		observed:     []string{"fd00::1-2"},
+		inUse:        "fd00::1-fd00::2",
+		leaked:       "[]",
+		unknown:      "[]",
+		leakedOwners: map[string]string{},
+	},
+	{
+		name:         "nothing recorded",
+		observed:     []string{"172.18.0.0/24"},
+		inUse:        "[]",
+		leaked:       "[]",
+		unknown:      "172.18.0.0/24",
+		leakedOwners: map[string]string{},
+	},
+	{
+		name: "nothing observed",
+		recorded: []Reservation{
+			{net.ParseIP("172.18.0.1"), "a"},
+			{net.ParseIP("172.18.0.2"), "b"},
+		},
+		inUse:        "[]",
+		leaked:       "172.18.0.1-172.18.0.2",
+		unknown:      "[]",
+		leakedOwners: map[string]string{"a": "172.18.0.1", "b": "172.18.0.2"},
+	},
+	{
+		name:     "dual-stack",
+		recorded: []Reservation{{net.ParseIP("172.18.0.1"), "a"}},
+		observed: []string{"fd00::1"},
+		err:      errDualStackIPRanges,
+	},
+	{
+		name:     "invalid",
+		recorded: []Reservation{{nil, "a"}},
+		err:      errInvalidIPRangeFormat,
+	},
+}
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+	for _, test := range reconcileTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			observed, err := Parse(test.observed...)
+			if err != nil {
+				t.Fatalf("Parse(%q) err %q", test.observed, err)
+			}
+
+			report, err := Reconcile(test.recorded, observed)
+			if err != nil || test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("Reconcile(%v) err %v, want %v", observed, err, test.err)
+				}
+				return
+			}
+
+			if s := report.InUse.String(); s != test.inUse {
+				t.Fatalf("Reconcile(%v).InUse = %v, want %v", observed, s, test.inUse)
+			}
+			if s := report.Leaked.String(); s != test.leaked {
+				t.Fatalf("Reconcile(%v).Leaked = %v, want %v", observed, s, test.leaked)
+			}
+			if s := report.Unknown.String(); s != test.unknown {
+				t.Fatalf("Reconcile(%v).Unknown = %v, want %v", observed, s, test.unknown)
+			}
+			if clean := report.Clean(); clean != (test.leaked == "[]" && test.unknown == "[]") {
+				t.Fatalf("Reconcile(%v).Clean() = %v", observed, clean)
+			}
+			if len(report.LeakedOwners) != len(test.leakedOwners) {
+				t.Fatalf("Reconcile(%v).LeakedOwners = %v, want %v", observed, report.LeakedOwners, test.leakedOwners)
+			}
+			for owner, want := range test.leakedOwners {
+				if s := report.LeakedOwners[owner].String(); s != want {
+					t.Fatalf("Reconcile(%v).LeakedOwners[%q] = %v, want %v", observed, owner, s, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+//go:build go1.23
+
+package iprange
+
+import (
+	"iter"
+	"math/big"
+	"net"
+)
+
+// All returns an iterator over the IP addresses of IPRanges rr, which is
+// built on IPIterator:
+//
+//	for ip := range ranges.All() {
+//		// Do something.
+//	}
+func (rr *IPRanges) All() iter.Seq[net.IP] {
+	return seqIPs(rr.ranges)
+}
+
+// Blocks returns an iterator over the IP blocks of IPRanges rr, which is
+// built on BlockIterator.
+func (rr *IPRanges) Blocks(blockSize *big.Int) iter.Seq[*IPRanges] {
+	return func(yield func(*IPRanges) bool) {
+		bi := rr.BlockIterator(blockSize)
+		for block := bi.Next(); block != nil; block = bi.Next() {
+			if !yield(block) {
+				return
+			}
+		}
+	}
+}
+
+// Prefixes returns an iterator over the CIDR of IPRanges rr, which is
+// built on CIDRIterator.
+func (rr *IPRanges) Prefixes() iter.Seq[*net.IPNet] {
+	return seqCIDRs(rr.ranges)
+}
+
+// All returns an iterator over the IP addresses of DualStackRanges ds,
+// IPv4 addresses first and IPv6 addresses after.
+func (ds *DualStackRanges) All() iter.Seq[net.IP] {
+	return seqIPs(ds.ranges())
+}
+
+// Blocks returns an iterator over the IP blocks of DualStackRanges ds,
+// which is built on BlockIterator.
+func (ds *DualStackRanges) Blocks(blockSize *big.Int) iter.Seq[*DualStackRanges] {
+	return func(yield func(*DualStackRanges) bool) {
+		bi := ds.BlockIterator(blockSize)
+		for block := bi.Next(); block != nil; block = bi.Next() {
+			if !yield(block) {
+				return
+			}
+		}
+	}
+}
+
+// Prefixes returns an iterator over the CIDR of DualStackRanges ds, IPv4
+// CIDR first and IPv6 CIDR after.
+func (ds *DualStackRanges) Prefixes() iter.Seq[*net.IPNet] {
+	return seqCIDRs(ds.ranges())
+}
+
+// seqIPs returns an iterator over the IP addresses of ranges, each use of
+// which scans with a new ipIterator.
+func seqIPs(ranges []ipRange) iter.Seq[net.IP] {
+	return func(yield func(net.IP) bool) {
+		ii := &ipIterator{ranges: ranges}
+		for {
+			ip, ok := ii.next()
+			if !ok || !yield(ip.toIP()) {
+				return
+			}
+		}
+	}
+}
+
+// seqCIDRs returns an iterator over the CIDR of ranges, each use of which
+// scans with a new cidrIterator.
+func seqCIDRs(ranges []ipRange) iter.Seq[*net.IPNet] {
+	return func(yield func(*net.IPNet) bool) {
+		ci := newCIDRIterator(ranges)
+		for cidr := ci.Next(); cidr != nil; cidr = ci.Next() {
+			if !yield(cidr) {
+				return
+			}
+		}
+	}
+}
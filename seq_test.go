@@ -0,0 +1,125 @@
+//go:build go1.23
+
+package iprange
+
+import (
+	"math/big"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var seqTests = []struct {
+	name   string
+	ranges []string
+}{
+	{"IPv4", []string{"172.18.0.1-3", "172.18.0.0/30", "172.18.1.10"}},
+	{"IPv6", []string{"fd00::/126", "fd00::1:1-5"}},
+	{"empty", []string{}},
+}
+
+func TestIPRangesSeq(t *testing.T) {
+	t.Parallel()
+	for _, test := range seqTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ranges, err := Parse(test.ranges...)
+			if err != nil {
+				t.Fatalf("Parse(%q) err %q", test.ranges, err)
+			}
+
+			var ips, want []net.IP
+			for ip := range ranges.All() {
+				ips = append(ips, ip)
+			}
+			ii := ranges.IPIterator()
+			for ip := ii.Next(); ip != nil; ip = ii.Next() {
+				want = append(want, ip)
+			}
+			if !cmp.Equal(ips, want) {
+				t.Fatalf("IPRanges(%v).All() = %v, want %v", ranges, ips, want)
+			}
+
+			var blocks, wantBlocks []string
+			for block := range ranges.Blocks(big.NewInt(3)) {
+				blocks = append(blocks, block.String())
+			}
+			bi := ranges.BlockIterator(big.NewInt(3))
+			for block := bi.Next(); block != nil; block = bi.Next() {
+				wantBlocks = append(wantBlocks, block.String())
+			}
+			if !cmp.Equal(blocks, wantBlocks) {
+				t.Fatalf("IPRanges(%v).Blocks(3) = %v, want %v", ranges, blocks, wantBlocks)
+			}
+
+			var prefixes, wantPrefixes []*net.IPNet
+			for prefix := range ranges.Prefixes() {
+				prefixes = append(prefixes, prefix)
+			}
+			ci := ranges.CIDRIterator()
+			for prefix := ci.Next(); prefix != nil; prefix = ci.Next() {
+				wantPrefixes = append(wantPrefixes, prefix)
+			}
+			if !cmp.Equal(prefixes, wantPrefixes) {
+				t.Fatalf("IPRanges(%v).Prefixes() = %v, want %v", ranges, prefixes, wantPrefixes)
+			}
+		})
+	}
+}
+
+func TestIPRangesSeqBreak(t *testing.T) {
+	t.Parallel()
+	ranges, err := Parse("172.18.0.0/24")
+	if err != nil {
+		t.Fatalf("Parse() err %q", err)
+	}
+
+	// Each use of the iterator starts over, and break stops it.
+	seq := ranges.All()
+	for i := 0; i < 2; i++ {
+		var ips []string
+		for ip := range seq {
+			if len(ips) == 2 {
+				break
+			}
+			ips = append(ips, ip.String())
+		}
+		if want := []string{"172.18.0.0", "172.18.0.1"}; !cmp.Equal(ips, want) {
+			t.Fatalf("IPRanges(%v).All() = %v, want %v", ranges, ips, want)
+		}
+	}
+}
+
+func TestDualStackRangesSeq(t *testing.T) {
+	t.Parallel()
+	ds, err := ParseDualStack("fd00::1-2", "172.18.0.1-2")
+	if err != nil {
+		t.Fatalf("ParseDualStack() err %q", err)
+	}
+
+	var ips []string
+	for ip := range ds.All() {
+		ips = append(ips, ip.String())
+	}
+	if want := []string{"172.18.0.1", "172.18.0.2", "fd00::1", "fd00::2"}; !cmp.Equal(ips, want) {
+		t.Fatalf("DualStackRanges(%v).All() = %v, want %v", ds, ips, want)
+	}
+
+	var blocks []string
+	for block := range ds.Blocks(big.NewInt(3)) {
+		blocks = append(blocks, block.String())
+	}
+	if want := []string{"[172.18.0.1-172.18.0.2 fd00::1]", "fd00::2"}; !cmp.Equal(blocks, want) {
+		t.Fatalf("DualStackRanges(%v).Blocks(3) = %v, want %v", ds, blocks, want)
+	}
+
+	var prefixes []string
+	for prefix := range ds.Prefixes() {
+		prefixes = append(prefixes, prefix.String())
+	}
+	if want := []string{"172.18.0.1/32", "172.18.0.2/32", "fd00::1/128", "fd00::2/128"}; !cmp.Equal(prefixes, want) {
+		t.Fatalf("DualStackRanges(%v).Prefixes() = %v, want %v", ds, prefixes, want)
+	}
+}
@@ -0,0 +1,228 @@
+package iprange
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+)
+
+// Reservation is an IP address reserved by an owner, such as the ID of a
+// container.
+type Reservation struct {
+	IP    net.IP
+	Owner string
+}
+
+// Store persists the reservations of IP addresses, so that they survive
+// restarts. The methods of a Store, apart from Lock and Unlock, are not
+// atomic with each other: hold the lock of the Store while performing a
+// sequence of them, such as listing the reservations and reserving a free
+// IP address, see AllocateFromStore.
+type Store interface {
+	// Lock acquires the lock of the Store, which excludes all the other
+	// users of the Store, including other processes if the Store is
+	// shared between processes.
+	Lock() error
+
+	// Unlock releases the lock of the Store.
+	Unlock() error
+
+	// Reserve reserves net.IP ip for owner. It reports false if ip has
+	// already been reserved.
+	Reserve(ip net.IP, owner string) (bool, error)
+
+	// Release releases net.IP ip, which is a no-op if ip has not been
+	// reserved.
+	Release(ip net.IP) error
+
+	// ReleaseOwner releases all the IP addresses reserved for owner.
+	ReleaseOwner(owner string) error
+
+	// List returns the IP addresses reserved for owner, in ascending
+	// order.
+	List(owner string) ([]net.IP, error)
+
+	// Reservations returns all the reservations, in ascending order of
+	// their IP addresses.
+	Reservations() ([]Reservation, error)
+}
+
+// AllocateFromStore allocates a free IP address of IPRanges pool for owner,
+// and reserves it in Store s. An IP address is free if it has not been
+// reserved in s. Which free IP address to allocate is decided by Strategy
+// strategy, or LowestFree if strategy is nil. The lock of s is held during
+// the allocation, so that concurrent allocations from the same pool never
+// result in the same IP address.
+//
+// The error errPoolExhausted will be returned when there is no free IP
+// address.
+func AllocateFromStore(s Store, pool *IPRanges, strategy Strategy, owner string) (ip net.IP, err error) {
+	if strategy == nil {
+		strategy = LowestFree{}
+	}
+
+	if err := s.Lock(); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if uerr := s.Unlock(); err == nil {
+			err = uerr
+		}
+	}()
+
+	rs, err := s.Reservations()
+	if err != nil {
+		return nil, err
+	}
+
+	var b IPRangesBuilder
+	for _, r := range rs {
+		if pool.Contains(r.IP) {
+			b.AddIP(r.IP)
+		}
+	}
+	used, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	ip, err = strategy.Pick(pool, used)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.Reserve(ip, owner)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errAllocated, ip)
+	}
+
+	return ip, nil
+}
+
+// MemoryStore is a Store which keeps the reservations in memory, it is
+// shared between goroutines, but not between processes.
+type MemoryStore struct {
+	lock sync.Mutex
+
+	mu           sync.Mutex
+	reservations map[xIP]string
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		reservations: make(map[xIP]string),
+	}
+}
+
+// Lock implements Store.
+func (s *MemoryStore) Lock() error {
+	s.lock.Lock()
+
+	return nil
+}
+
+// Unlock implements Store.
+func (s *MemoryStore) Unlock() error {
+	s.lock.Unlock()
+
+	return nil
+}
+
+// Reserve implements Store.
+func (s *MemoryStore) Reserve(ip net.IP, owner string) (bool, error) {
+	w := ipToXIP(ip)
+	if w.version() == Unknown {
+		return false, fmt.Errorf("%w: %s", errInvalidIPRangeFormat, ip)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.reservations[w]; ok {
+		return false, nil
+	}
+	s.reservations[w] = owner
+
+	return true, nil
+}
+
+// Release implements Store.
+func (s *MemoryStore) Release(ip net.IP) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.reservations, ipToXIP(ip))
+
+	return nil
+}
+
+// ReleaseOwner implements Store.
+func (s *MemoryStore) ReleaseOwner(owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for w, o := range s.reservations {
+		if o == owner {
+			delete(s.reservations, w)
+		}
+	}
+
+	return nil
+}
+
+// List implements Store.
+func (s *MemoryStore) List(owner string) ([]net.IP, error) {
+	rs, _ := s.Reservations()
+
+	return ownedIPs(rs, owner), nil
+}
+
+// Reservations implements Store.
+func (s *MemoryStore) Reservations() ([]Reservation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ws := make([]xIP, 0, len(s.reservations))
+	for w := range s.reservations {
+		ws = append(ws, w)
+	}
+	sortXIPs(ws)
+
+	rs := make([]Reservation, 0, len(ws))
+	for _, w := range ws {
+		rs = append(rs, Reservation{
+			IP:    w.toIP(),
+			Owner: s.reservations[w],
+		})
+	}
+
+	return rs, nil
+}
+
+// ownedIPs returns the IP addresses of reservations rs that are reserved
+// for owner.
+func ownedIPs(rs []Reservation, owner string) []net.IP {
+	var ips []net.IP
+	for _, r := range rs {
+		if r.Owner == owner {
+			ips = append(ips, r.IP)
+		}
+	}
+
+	return ips
+}
+
+// sortXIPs sorts ws in ascending order, IPv4 before IPv6.
+func sortXIPs(ws []xIP) {
+	sort.Slice(ws, func(i, j int) bool {
+		if ws[i].version() != ws[j].version() {
+			return ws[i].version() < ws[j].version()
+		}
+		return ws[i].cmp(ws[j]) < 0
+	})
+}
@@ -0,0 +1,230 @@
+package iprange
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// lockFileName is the name of the lock file of a DirStore.
+const lockFileName = "lock"
+
+// DirStore is a Store which keeps the reservations in a local directory,
+// in the spirit of the host-local IPAM plugin of CNI: each reserved IP
+// address is a file named after it, which holds the owner. A reservation
+// file is written to a temporary file first, and then hard linked to its
+// name, so that it is never seen half written, even if the process crashes,
+// and an existing reservation is never overwritten.
+//
+// The lock of a DirStore is a file lock on the file "lock" in the
+// directory, so a directory can be shared by DirStores of multiple
+// processes on the same host. On platforms without file locks, the lock
+// only excludes the users of the same DirStore.
+type DirStore struct {
+	dir string
+
+	// mu excludes the goroutines sharing the DirStore, as a file lock is
+	// held per open file rather than per goroutine.
+	mu   sync.Mutex
+	lock *os.File
+}
+
+// NewDirStore returns a DirStore which keeps the reservations in
+// directory dir, dir is created if it does not exist.
+func NewDirStore(dir string) (*DirStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	lock, err := os.OpenFile(filepath.Join(dir, lockFileName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DirStore{
+		dir:  dir,
+		lock: lock,
+	}, nil
+}
+
+// Close closes DirStore s, which releases its lock if it is held.
+func (s *DirStore) Close() error {
+	return s.lock.Close()
+}
+
+// Lock implements Store.
+func (s *DirStore) Lock() error {
+	s.mu.Lock()
+	if err := lockFile(s.lock); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// Unlock implements Store.
+func (s *DirStore) Unlock() error {
+	defer s.mu.Unlock()
+
+	return unlockFile(s.lock)
+}
+
+// Reserve implements Store.
+func (s *DirStore) Reserve(ip net.IP, owner string) (bool, error) {
+	w := ipToXIP(ip)
+	if w.version() == Unknown {
+		return false, fmt.Errorf("%w: %s", errInvalidIPRangeFormat, ip)
+	}
+
+	return createFileAtomic(filepath.Join(s.dir, w.String()), []byte(owner))
+}
+
+// Release implements Store.
+func (s *DirStore) Release(ip net.IP) error {
+	w := ipToXIP(ip)
+	if w.version() == Unknown {
+		return nil
+	}
+
+	err := os.Remove(filepath.Join(s.dir, w.String()))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// ReleaseOwner implements Store.
+func (s *DirStore) ReleaseOwner(owner string) error {
+	ips, err := s.List(owner)
+	if err != nil {
+		return err
+	}
+
+	for _, ip := range ips {
+		if err := s.Release(ip); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// List implements Store.
+func (s *DirStore) List(owner string) ([]net.IP, error) {
+	rs, err := s.Reservations()
+	if err != nil {
+		return nil, err
+	}
+
+	return ownedIPs(rs, owner), nil
+}
+
+// Reservations implements Store. The files in the directory that are not
+// named after IP addresses, such as the lock file, are ignored.
+func (s *DirStore) Reservations() ([]Reservation, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := make(map[xIP]string, len(entries))
+	ws := make([]xIP, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		w := ipToXIP(net.ParseIP(entry.Name()))
+		if w.version() == Unknown {
+			continue
+		}
+
+		owner, err := readOwner(filepath.Join(s.dir, entry.Name()))
+		if errors.Is(err, fs.ErrNotExist) {
+			// Released in the meantime.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		owners[w] = owner
+		ws = append(ws, w)
+	}
+	sortXIPs(ws)
+
+	rs := make([]Reservation, 0, len(ws))
+	for _, w := range ws {
+		rs = append(rs, Reservation{
+			IP:    w.toIP(),
+			Owner: owners[w],
+		})
+	}
+
+	return rs, nil
+}
+
+// readOwner reads the owner from reservation file path, which is its first
+// line. The host-local IPAM plugin of CNI writes the interface name on the
+// second line, which is ignored.
+func readOwner(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	return strings.TrimSpace(line), nil
+}
+
+// createFileAtomic creates file path with data by hard linking a temporary
+// file in the same directory to it, which fails rather than overwrites if
+// path exists, in which case created is false. The directory is synced
+// after, so that the file survives a crash once created.
+func createFileAtomic(path string, data []byte) (created bool, err error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		f.Close()
+		os.Remove(f.Name())
+	}()
+
+	if _, err := f.Write(data); err != nil {
+		return false, err
+	}
+	if err := f.Sync(); err != nil {
+		return false, err
+	}
+	if err := f.Chmod(0o644); err != nil {
+		return false, err
+	}
+	if err := f.Close(); err != nil {
+		return false, err
+	}
+
+	if err := os.Link(f.Name(), path); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := syncDir(filepath.Dir(path)); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
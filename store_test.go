@@ -0,0 +1,262 @@
+package iprange
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var storeTests = []struct {
+	name     string
+	newStore func(t *testing.T) Store
+}{
+	{
+		name: "memory",
+		newStore: func(t *testing.T) Store {
+			return NewMemoryStore()
+		},
+	},
+	{
+		name: "dir",
+		newStore: func(t *testing.T) Store {
+			s, err := NewDirStore(filepath.Join(t.TempDir(), "pool"))
+			if err != nil {
+				t.Fatalf("NewDirStore() err %q", err)
+			}
+			t.Cleanup(func() { s.Close() })
+			return s
+		},
+	},
+}
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+	for _, test := range storeTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			s := test.newStore(t)
+			reserve := []Reservation{
+				{net.ParseIP("fd00::1"), "c"},
+				{net.ParseIP("172.18.0.10"), "a"},
+				{net.ParseIP("172.18.0.2"), "b"},
+				{net.ParseIP("172.18.0.1"), "a"},
+			}
+			for _, r := range reserve {
+				ok, err := s.Reserve(r.IP, r.Owner)
+				if err != nil || !ok {
+					t.Fatalf("Store.Reserve(%v, %v) = %v, %v, want true", r.IP, r.Owner, ok, err)
+				}
+			}
+			if ok, err := s.Reserve(net.ParseIP("172.18.0.1"), "b"); err != nil || ok {
+				t.Fatalf("Store.Reserve(172.18.0.1, b) = %v, %v, want false", ok, err)
+			}
+			if _, err := s.Reserve(nil, "b"); !errors.Is(err, errInvalidIPRangeFormat) {
+				t.Fatalf("Store.Reserve(nil, b) err %v, want %v", err, errInvalidIPRangeFormat)
+			}
+
+			rs, err := s.Reservations()
+			if err != nil {
+				t.Fatalf("Store.Reservations() err %q", err)
+			}
+			want := []Reservation{
+				{net.ParseIP("172.18.0.1").To4(), "a"},
+				{net.ParseIP("172.18.0.2").To4(), "b"},
+				{net.ParseIP("172.18.0.10").To4(), "a"},
+				{net.ParseIP("fd00::1"), "c"},
+			}
+			if !cmp.Equal(rs, want) {
+				t.Fatalf("Store.Reservations() = %v, want %v", rs, want)
+			}
+
+			ips, err := s.List("a")
+			if err != nil {
+				t.Fatalf("Store.List(a) err %q", err)
+			}
+			if want := []net.IP{want[0].IP, want[2].IP}; !cmp.Equal(ips, want) {
+				t.Fatalf("Store.List(a) = %v, want %v", ips, want)
+			}
+
+			if err := s.ReleaseOwner("a"); err != nil {
+				t.Fatalf("Store.ReleaseOwner(a) err %q", err)
+			}
+			if err := s.Release(net.ParseIP("fd00::1")); err != nil {
+				t.Fatalf("Store.Release(fd00::1) err %q", err)
+			}
+			if err := s.Release(net.ParseIP("fd00::1")); err != nil {
+				t.Fatalf("Store.Release(fd00::1) err %q", err)
+			}
+			rs, err = s.Reservations()
+			if err != nil {
+				t.Fatalf("Store.Reservations() err %q", err)
+			}
+			if want := want[1:2]; !cmp.Equal(rs, want) {
+				t.Fatalf("Store.Reservations() = %v, want %v", rs, want)
+			}
+		})
+	}
+}
+
+func TestAllocateFromStore(t *testing.T) {
+	t.Parallel()
+	for _, test := range storeTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			pool, err := Parse("172.18.0.1-3")
+			if err != nil {
+				t.Fatalf("Parse() err %q", err)
+			}
+
+			s := test.newStore(t)
+			if _, err := s.Reserve(net.ParseIP("172.18.0.1"), "a"); err != nil {
+				t.Fatalf("Store.Reserve(172.18.0.1, a) err %q", err)
+			}
+			if _, err := s.Reserve(net.ParseIP("172.18.1.1"), "a"); err != nil {
+				t.Fatalf("Store.Reserve(172.18.1.1, a) err %q", err)
+			}
+
+			var ips []string
+			for _, owner := range []string{"b", "c"} {
+				ip, err := AllocateFromStore(s, pool, nil, owner)
+				if err != nil {
+					t.Fatalf("AllocateFromStore(%v, %v) err %q", pool, owner, err)
+				}
+				ips = append(ips, ip.String())
+			}
+			if want := []string{"172.18.0.2", "172.18.0.3"}; !cmp.Equal(ips, want) {
+				t.Fatalf("AllocateFromStore(%v) = %v, want %v", pool, ips, want)
+			}
+			if _, err := AllocateFromStore(s, pool, nil, "d"); !errors.Is(err, errPoolExhausted) {
+				t.Fatalf("AllocateFromStore(%v) err %v, want %v", pool, err, errPoolExhausted)
+			}
+		})
+	}
+}
+
+func TestDirStoreConcurrent(t *testing.T) {
+	t.Parallel()
+	pool, err := Parse("172.18.0.0/26")
+	if err != nil {
+		t.Fatalf("Parse() err %q", err)
+	}
+
+	// Each DirStore has its own lock file, as if it were another process.
+	dir := t.TempDir()
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		ips = make(map[string]bool)
+	)
+	for i := 0; i < 4; i++ {
+		s, err := NewDirStore(dir)
+		if err != nil {
+			t.Fatalf("NewDirStore() err %q", err)
+		}
+		defer s.Close()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				ip, err := AllocateFromStore(s, pool, nil, "owner")
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				ips[ip.String()] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(ips) != 64 {
+		t.Fatalf("AllocateFromStore(%v) allocated %d distinct IP addresses, want 64", pool, len(ips))
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("os.ReadDir() err %q", err)
+	}
+	if len(entries) != 65 {
+		t.Fatalf("DirStore has %d files, want 64 reservations and the lock", len(entries))
+	}
+}
+
+func TestDirStoreReserveRace(t *testing.T) {
+	t.Parallel()
+	ip := net.ParseIP("172.18.0.1")
+
+	// The DirStores reserve the same IP address without the lock, and only
+	// one of them may succeed.
+	dir := t.TempDir()
+	var (
+		wg       sync.WaitGroup
+		reserved atomic.Int32
+	)
+	for i := 0; i < 8; i++ {
+		s, err := NewDirStore(dir)
+		if err != nil {
+			t.Fatalf("NewDirStore() err %q", err)
+		}
+		defer s.Close()
+
+		owner := fmt.Sprintf("owner-%d", i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := s.Reserve(ip, owner)
+			if err != nil {
+				t.Errorf("DirStore.Reserve(%v) err %q", ip, err)
+			}
+			if ok {
+				reserved.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := reserved.Load(); n != 1 {
+		t.Fatalf("DirStore.Reserve(%v) succeeded %d times, want 1", ip, n)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("os.ReadDir() err %q", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("DirStore has %d files, want the reservation and the lock", len(entries))
+	}
+}
+
+func TestDirStoreHostLocal(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	// A reservation file written by the host-local IPAM plugin of CNI.
+	if err := os.WriteFile(filepath.Join(dir, "172.18.0.1"), []byte("container-1\r\neth0"), 0o644); err != nil {
+		t.Fatalf("os.WriteFile() err %q", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "last_reserved_ip.0"), []byte("172.18.0.1"), 0o644); err != nil {
+		t.Fatalf("os.WriteFile() err %q", err)
+	}
+
+	s, err := NewDirStore(dir)
+	if err != nil {
+		t.Fatalf("NewDirStore() err %q", err)
+	}
+	defer s.Close()
+
+	rs, err := s.Reservations()
+	if err != nil {
+		t.Fatalf("DirStore.Reservations() err %q", err)
+	}
+	if want := []Reservation{{net.ParseIP("172.18.0.1").To4(), "container-1"}}; !cmp.Equal(rs, want) {
+		t.Fatalf("DirStore.Reservations() = %v, want %v", rs, want)
+	}
+}
@@ -0,0 +1,208 @@
+package iprange
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/big"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// Strategy decides which free IP address to allocate from a pool, i.e. how
+// IP addresses are placed within the pool.
+type Strategy interface {
+	// Pick returns a free IP address of IPRanges pool, which does not
+	// pertain to IPRanges used. The error errPoolExhausted will be
+	// returned when there is no such IP address.
+	Pick(pool, used *IPRanges) (net.IP, error)
+}
+
+// LowestFree is the Strategy that picks the lowest free IP address.
+type LowestFree struct{}
+
+// Pick implements Strategy.
+func (LowestFree) Pick(pool, used *IPRanges) (net.IP, error) {
+	free := pool.Diff(used)
+	if len(free.ranges) == 0 {
+		return nil, errPoolExhausted
+	}
+
+	return free.ranges[0].start.toIP(), nil
+}
+
+// RoundRobin is the Strategy that picks the lowest free IP address after
+// the last one it picked, and wraps around to the start of the pool, like
+// the host-local IPAM plugin of CNI does. It is safe for concurrent use by
+// multiple goroutines.
+type RoundRobin struct {
+	mu   sync.Mutex
+	last xIP
+}
+
+// NewRoundRobin returns a RoundRobin that picks IP addresses after net.IP
+// last, which is usually the last reserved IP address persisted somewhere.
+// If last is nil, it starts from the start of the pool.
+func NewRoundRobin(last net.IP) *RoundRobin {
+	return &RoundRobin{
+		last: ipToXIP(last),
+	}
+}
+
+// Pick implements Strategy.
+func (s *RoundRobin) Pick(pool, used *IPRanges) (net.IP, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	free := pool.Diff(used)
+	if len(free.ranges) == 0 {
+		return nil, errPoolExhausted
+	}
+
+	w := free.ranges[0].start
+	if s.last.version() == free.version {
+		w = firstFreeFrom(free.ranges, s.last.next())
+	}
+	s.last = w
+
+	return w.toIP(), nil
+}
+
+// Last returns the last IP address that RoundRobin s picked, or nil if it
+// has not picked any yet.
+func (s *RoundRobin) Last() net.IP {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.last.version() == Unknown {
+		return nil
+	}
+
+	return s.last.toIP()
+}
+
+// Random is the Strategy that picks a free IP address uniformly at random.
+// It is safe for concurrent use by multiple goroutines.
+type Random struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// NewRandom returns a Random that draws random numbers from rand.Source
+// src. If src is nil, a source seeded with the current time is used.
+func NewRandom(src rand.Source) *Random {
+	if src == nil {
+		src = rand.NewSource(time.Now().UnixNano())
+	}
+
+	return &Random{
+		rnd: rand.New(src),
+	}
+}
+
+// Pick implements Strategy.
+func (s *Random) Pick(pool, used *IPRanges) (net.IP, error) {
+	free := pool.Diff(used)
+	if len(free.ranges) == 0 {
+		return nil, errPoolExhausted
+	}
+
+	s.mu.Lock()
+	n := new(big.Int).Rand(s.rnd, free.Size())
+	s.mu.Unlock()
+
+	return nthIP(free, n).toIP(), nil
+}
+
+// Sticky is the Strategy that picks a free IP address deterministically by
+// Key, such as the ID of a pod or container. Key is hashed to an IP address
+// of the pool, and the IP addresses from there on are probed in order,
+// wrapping around to the start of the pool, until a free one is found. So
+// the same Key always results in the same IP address, as long as it is
+// free.
+type Sticky struct {
+	Key string
+}
+
+// Pick implements Strategy.
+func (s Sticky) Pick(pool, used *IPRanges) (net.IP, error) {
+	free := pool.Diff(used)
+	if len(free.ranges) == 0 {
+		return nil, errPoolExhausted
+	}
+
+	// The pool is merged first, so that its overlapping parts do not count
+	// twice in the index.
+	m := pool.Merge()
+	h := fnv.New128a()
+	h.Write([]byte(s.Key))
+	n := new(big.Int).SetBytes(h.Sum(nil))
+	n.Mod(n, m.Size())
+
+	return firstFreeFrom(free.ranges, nthIP(m, n)).toIP(), nil
+}
+
+// SetStrategy sets the Strategy that Allocate uses for Allocator a. If s
+// is nil, Allocate allocates the lowest free IP address.
+func (a *Allocator) SetStrategy(s Strategy) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.strategy = s
+}
+
+// AllocateWith allocates the free IP address picked by Strategy s, instead
+// of the Strategy set by SetStrategy. The error errPoolExhausted will be
+// returned when there is no free IP address.
+func (a *Allocator) AllocateWith(s Strategy) (net.IP, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.free) == 0 {
+		return nil, errPoolExhausted
+	}
+
+	return a.allocateWith(s)
+}
+
+// allocateWith allocates the free IP address picked by Strategy s.
+func (a *Allocator) allocateWith(s Strategy) (net.IP, error) {
+	ip, err := s.Pick(a.Pool(), a.usedRanges())
+	if err != nil {
+		return nil, err
+	}
+
+	w := ipToXIP(ip)
+	if !a.inPool(w) {
+		return nil, fmt.Errorf("%w: %s", errNotInPool, ip)
+	}
+	if !rangesContain(a.free, w) {
+		return nil, fmt.Errorf("%w: %s", errAllocated, ip)
+	}
+	a.allocate(w)
+
+	return ip, nil
+}
+
+// firstFreeFrom returns the lowest xIP in merged free ranges that is not
+// lower than xIP w, or the lowest one of free ranges if there is no such
+// xIP. free ranges must not be empty.
+func firstFreeFrom(free []ipRange, w xIP) xIP {
+	i := searchRanges(free, func(r ipRange) bool {
+		return r.end.cmp(w) >= 0
+	})
+	if i == len(free) {
+		return free[0].start
+	}
+
+	return maxXIP(free[i].start, w)
+}
+
+// nthIP returns the xIP at index n of IPRanges rr, n must be less than the
+// size of rr.
+func nthIP(rr *IPRanges, n *big.Int) xIP {
+	ip, _ := rr.IPIterator().nextN(new(big.Int).Add(n, bigInt[1]))
+
+	return ip
+}
@@ -0,0 +1,180 @@
+package iprange
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var strategyTests = []struct {
+	name     string
+	strategy func() Strategy
+	pool     []string
+	used     []string
+	want     []string
+}{
+	{
+		name:     "lowest free",
+		strategy: func() Strategy { return LowestFree{} },
+		pool:     []string{"172.18.0.1-5"},
+		used:     []string{"172.18.0.1", "172.18.0.3"},
+		want:     []string{"172.18.0.2", "172.18.0.4", "172.18.0.5"},
+	},
+	{
+		name:     "round robin",
+		strategy: func() Strategy { return NewRoundRobin(nil) },
+		pool:     []string{"172.18.0.1-3", "172.18.0.10"},
+		used:     []string{"172.18.0.2"},
+		want:     []string{"172.18.0.1", "172.18.0.3", "172.18.0.10"},
+	},
+	{
+		name:     "round robin after last",
+		strategy: func() Strategy { return NewRoundRobin(net.ParseIP("172.18.0.3")) },
+		pool:     []string{"172.18.0.1-5"},
+		used:     []string{"172.18.0.5"},
+		want:     []string{"172.18.0.4", "172.18.0.1", "172.18.0.2", "172.18.0.3"},
+	},
+	{
+		name:     "round robin last out of pool",
+		strategy: func() Strategy { return NewRoundRobin(net.ParseIP("fd00::1")) },
+		pool:     []string{"172.18.0.1-2"},
+		want:     []string{"172.18.0.1", "172.18.0.2"},
+	},
+	{
+		name:     "round robin last max",
+		strategy: func() Strategy { return NewRoundRobin(net.ParseIP("255.255.255.255")) },
+		pool:     []string{"255.255.255.254/31"},
+		want:     []string{"255.255.255.254", "255.255.255.255"},
+	},
+	{
+		name:     "sticky",
+		strategy: func() Strategy { return Sticky{Key: "pod-1"} },
+		pool:     []string{"172.18.0.1-5"},
+		used:     []string{"172.18.0.4"},
+		want:     []string{"172.18.0.3", "172.18.0.5", "172.18.0.1", "172.18.0.2"},
+	},
+}
+
+func TestStrategy(t *testing.T) {
+	t.Parallel()
+	for _, test := range strategyTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			pool, err := Parse(test.pool...)
+			if err != nil {
+				t.Fatalf("Parse(%q) err %q", test.pool, err)
+			}
+
+			a := NewAllocator(pool)
+			for _, ip := range test.used {
+				if err := a.AllocateSpecific(net.ParseIP(ip)); err != nil {
+					t.Fatalf("Allocator(%v).AllocateSpecific(%v) err %q", pool, ip, err)
+				}
+			}
+			a.SetStrategy(test.strategy())
+
+			var ips []string
+			for {
+				ip, err := a.Allocate()
+				if err != nil {
+					if !errors.Is(err, errPoolExhausted) {
+						t.Fatalf("Allocator(%v).Allocate() err %q", pool, err)
+					}
+					break
+				}
+				ips = append(ips, ip.String())
+			}
+			if !cmp.Equal(ips, test.want) {
+				t.Fatalf("Allocator(%v).Allocate() = %v, want %v", pool, ips, test.want)
+			}
+		})
+	}
+}
+
+func TestStickyDeterministic(t *testing.T) {
+	t.Parallel()
+	pool, err := Parse("fd00::/64")
+	if err != nil {
+		t.Fatalf("Parse() err %q", err)
+	}
+
+	s := Sticky{Key: "container-1"}
+	ip, err := s.Pick(pool, &IPRanges{})
+	if err != nil {
+		t.Fatalf("Sticky(%v).Pick(%v) err %q", s.Key, pool, err)
+	}
+	used, _ := Parse(ip.String())
+	ip2, err := s.Pick(pool, used)
+	if err != nil {
+		t.Fatalf("Sticky(%v).Pick(%v) err %q", s.Key, pool, err)
+	}
+	if ip3, _ := s.Pick(pool, &IPRanges{}); !ip3.Equal(ip) {
+		t.Fatalf("Sticky(%v).Pick(%v) = %v, want %v", s.Key, pool, ip3, ip)
+	}
+	if want := ipToXIP(ip).next().toIP(); !ip2.Equal(want) {
+		t.Fatalf("Sticky(%v).Pick(%v) = %v with %v used, want %v", s.Key, pool, ip2, ip, want)
+	}
+}
+
+func TestStickyOverlappingPool(t *testing.T) {
+	t.Parallel()
+	pool, err := Parse("172.18.0.1-3", "172.18.0.1-3", "172.18.0.2-5")
+	if err != nil {
+		t.Fatalf("Parse() err %q", err)
+	}
+	merged := pool.Merge()
+
+	for i := 0; i < 32; i++ {
+		s := Sticky{Key: fmt.Sprintf("pod-%d", i)}
+		got, err := s.Pick(pool, &IPRanges{})
+		if err != nil {
+			t.Fatalf("Sticky(%v).Pick(%v) err %q", s.Key, pool, err)
+		}
+		want, err := s.Pick(merged, &IPRanges{})
+		if err != nil {
+			t.Fatalf("Sticky(%v).Pick(%v) err %q", s.Key, merged, err)
+		}
+		if !got.Equal(want) {
+			t.Fatalf("Sticky(%v).Pick(%v) = %v, want %v as on the merged pool", s.Key, pool, got, want)
+		}
+	}
+}
+
+func TestRandom(t *testing.T) {
+	t.Parallel()
+	pool, err := Parse("fd00::1-3", "fd00::10")
+	if err != nil {
+		t.Fatalf("Parse() err %q", err)
+	}
+	used, err := Parse("fd00::2")
+	if err != nil {
+		t.Fatalf("Parse() err %q", err)
+	}
+
+	// Every free IP address is picked sooner or later.
+	s := NewRandom(rand.NewSource(1))
+	free := pool.Diff(used)
+	picked := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		ip, err := s.Pick(pool, used)
+		if err != nil {
+			t.Fatalf("Random.Pick(%v, %v) err %q", pool, used, err)
+		}
+		if !free.Contains(ip) {
+			t.Fatalf("Random.Pick(%v, %v) = %v, want one of %v", pool, used, ip, free)
+		}
+		picked[ip.String()] = true
+	}
+	if len(picked) != 3 {
+		t.Fatalf("Random.Pick(%v, %v) picked %v, want all of %v", pool, used, picked, free)
+	}
+
+	if _, err := s.Pick(pool, pool); !errors.Is(err, errPoolExhausted) {
+		t.Fatalf("Random.Pick(%v, %v) err %v, want %v", pool, pool, err, errPoolExhausted)
+	}
+}
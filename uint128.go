@@ -0,0 +1,144 @@
+package iprange
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// uint128 represents a 128-bit unsigned integer, which is wide enough to
+// hold any IPv4 or IPv6 address. hi holds the most significant 64 bits.
+type uint128 struct {
+	hi uint64
+	lo uint64
+}
+
+// maxUint128 is the largest value a uint128 can hold.
+var maxUint128 = uint128{^uint64(0), ^uint64(0)}
+
+// lowBits returns a uint128 with the n least significant bits set, n must be
+// in [0, 128].
+func lowBits(n int) uint128 {
+	if n >= 64 {
+		return uint128{^uint64(0) >> (128 - n), ^uint64(0)}
+	}
+
+	return uint128{0, ^uint64(0) >> (64 - n)}
+}
+
+// isZero reports whether u == 0.
+func (u uint128) isZero() bool {
+	return u.hi|u.lo == 0
+}
+
+// cmp compares uint128 u and v and returns:
+//
+//	-1: u <  v
+//	 0: u == v
+//	+1: u >  v
+func (u uint128) cmp(v uint128) int {
+	switch {
+	case u.hi < v.hi:
+		return -1
+	case u.hi > v.hi:
+		return 1
+	case u.lo < v.lo:
+		return -1
+	case u.lo > v.lo:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// add returns u+v, wrapping around on overflow.
+func (u uint128) add(v uint128) uint128 {
+	lo, carry := bits.Add64(u.lo, v.lo, 0)
+	hi, _ := bits.Add64(u.hi, v.hi, carry)
+
+	return uint128{hi, lo}
+}
+
+// sub returns u-v, wrapping around on underflow.
+func (u uint128) sub(v uint128) uint128 {
+	lo, borrow := bits.Sub64(u.lo, v.lo, 0)
+	hi, _ := bits.Sub64(u.hi, v.hi, borrow)
+
+	return uint128{hi, lo}
+}
+
+// addOne returns u+1, wrapping around on overflow.
+func (u uint128) addOne() uint128 {
+	lo, carry := bits.Add64(u.lo, 1, 0)
+
+	return uint128{u.hi + carry, lo}
+}
+
+// subOne returns u-1, wrapping around on underflow.
+func (u uint128) subOne() uint128 {
+	lo, borrow := bits.Sub64(u.lo, 1, 0)
+
+	return uint128{u.hi - borrow, lo}
+}
+
+// and returns u&v.
+func (u uint128) and(v uint128) uint128 {
+	return uint128{u.hi & v.hi, u.lo & v.lo}
+}
+
+// or returns u|v.
+func (u uint128) or(v uint128) uint128 {
+	return uint128{u.hi | v.hi, u.lo | v.lo}
+}
+
+// not returns ^u.
+func (u uint128) not() uint128 {
+	return uint128{^u.hi, ^u.lo}
+}
+
+// bitLen returns the minimum number of bits required to represent u.
+func (u uint128) bitLen() int {
+	if u.hi != 0 {
+		return 64 + bits.Len64(u.hi)
+	}
+
+	return bits.Len64(u.lo)
+}
+
+// trailingZeros returns the number of trailing zero bits in u. The result
+// is 128 for u == 0.
+func (u uint128) trailingZeros() int {
+	if u.lo != 0 {
+		return bits.TrailingZeros64(u.lo)
+	}
+
+	return 64 + bits.TrailingZeros64(u.hi)
+}
+
+// big converts uint128 u to a big number.
+func (u uint128) big() *big.Int {
+	i := new(big.Int).SetUint64(u.hi)
+	i.Lsh(i, 64)
+
+	return i.Or(i, new(big.Int).SetUint64(u.lo))
+}
+
+// uint128FromBig converts a non-negative big number to uint128. The bool
+// is false if i does not fit into 128 bits.
+func uint128FromBig(i *big.Int) (uint128, bool) {
+	if i.Sign() < 0 || i.BitLen() > 128 {
+		return uint128{}, false
+	}
+
+	var words [2]uint64
+	if bits.UintSize == 64 {
+		for k, w := range i.Bits() {
+			words[k] = uint64(w)
+		}
+	} else {
+		for k, w := range i.Bits() {
+			words[k/2] |= uint64(w) << (32 * (k % 2))
+		}
+	}
+
+	return uint128{words[1], words[0]}, true
+}
@@ -0,0 +1,89 @@
+package iprange
+
+import (
+	"math/big"
+	"testing"
+)
+
+var uint128ArithmeticTests = []struct {
+	name string
+	x    uint128
+	y    uint128
+	sum  uint128
+	diff uint128
+}{
+	{"small", uint128{0, 3}, uint128{0, 1}, uint128{0, 4}, uint128{0, 2}},
+	{"carry", uint128{0, ^uint64(0)}, uint128{0, 1}, uint128{1, 0}, uint128{0, ^uint64(0) - 1}},
+	{"borrow", uint128{1, 0}, uint128{0, 1}, uint128{1, 1}, uint128{0, ^uint64(0)}},
+	{"wrap around", maxUint128, uint128{0, 1}, uint128{}, uint128{^uint64(0), ^uint64(0) - 1}},
+}
+
+func TestUint128Arithmetic(t *testing.T) {
+	t.Parallel()
+	for _, test := range uint128ArithmeticTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if sum := test.x.add(test.y); sum != test.sum {
+				t.Fatalf("%v.add(%v) = %v, want %v", test.x, test.y, sum, test.sum)
+			}
+			if diff := test.x.sub(test.y); diff != test.diff {
+				t.Fatalf("%v.sub(%v) = %v, want %v", test.x, test.y, diff, test.diff)
+			}
+			if test.y == (uint128{0, 1}) {
+				if sum := test.x.addOne(); sum != test.sum {
+					t.Fatalf("%v.addOne() = %v, want %v", test.x, sum, test.sum)
+				}
+				if diff := test.x.subOne(); diff != test.diff {
+					t.Fatalf("%v.subOne() = %v, want %v", test.x, diff, test.diff)
+				}
+			}
+		})
+	}
+}
+
+var uint128BitsTests = []struct {
+	name          string
+	u             uint128
+	bitLen        int
+	trailingZeros int
+}{
+	{"zero", uint128{}, 0, 128},
+	{"one", uint128{0, 1}, 1, 0},
+	{"high", uint128{1 << 10, 0}, 75, 74},
+	{"max", maxUint128, 128, 0},
+}
+
+func TestUint128Bits(t *testing.T) {
+	t.Parallel()
+	for _, test := range uint128BitsTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if n := test.u.bitLen(); n != test.bitLen {
+				t.Fatalf("%v.bitLen() = %v, want %v", test.u, n, test.bitLen)
+			}
+			if n := test.u.trailingZeros(); n != test.trailingZeros {
+				t.Fatalf("%v.trailingZeros() = %v, want %v", test.u, n, test.trailingZeros)
+			}
+		})
+	}
+}
+
+func TestUint128Big(t *testing.T) {
+	t.Parallel()
+	for _, u := range []uint128{{}, {0, 1}, {1, 0}, {1 << 63, 42}, maxUint128} {
+		v, ok := uint128FromBig(u.big())
+		if !ok || v != u {
+			t.Fatalf("uint128FromBig(%v.big()) = %v, %v, want %v, true", u, v, ok, u)
+		}
+	}
+
+	tooBig := new(big.Int).Lsh(bigInt[1], 128)
+	if _, ok := uint128FromBig(tooBig); ok {
+		t.Fatalf("uint128FromBig(%v) ok = true, want false", tooBig)
+	}
+	if _, ok := uint128FromBig(big.NewInt(-1)); ok {
+		t.Fatalf("uint128FromBig(-1) ok = true, want false")
+	}
+}